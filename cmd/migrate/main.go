@@ -0,0 +1,96 @@
+// Command migrate applies, reverts and lists the embedded schema migrations.
+//
+//	migrate [-seed file] up
+//	migrate [-steps n] down
+//	migrate status
+//
+// The database is selected with the DB, DATABASE_URL and GORM_CONF env vars, as for the services.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/migrate"
+	"go.uber.org/zap"
+)
+
+func main() {
+	steps := flag.Int("steps", 1, "number of migrations to revert with down")
+	seed := flag.String("seed", "", "sql file to run after up when it created the schema from scratch")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [-steps n] [-seed file] up|down|status\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logger.Sync()
+
+	db, err := container.NewDBConnectionAdapter(strings.ToUpper(strings.TrimSpace(os.Getenv(container.DB))),
+		os.Getenv(container.DatabaseURL),
+		1,
+		1,
+		0,
+		0,
+		os.Getenv(container.GormConf)).MakeConnection()
+	if err != nil {
+		logger.Fatal("failed to connect", zap.Error(err))
+	}
+
+	migrator, err := migrate.New(db, logger)
+	if err != nil {
+		logger.Fatal("failed to load migrations", zap.Error(err))
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			logger.Fatal("migrate up failed", zap.Error(err))
+		}
+		logger.Info("migrate up done", zap.Int("applied", applied))
+		if *seed != "" && applied == len(migrator.Migrations()) {
+			if err := container.RunSQLFile(db, *seed); err != nil {
+				logger.Fatal("seed failed", zap.Error(err))
+			}
+			logger.Info("seed done", zap.String("file", *seed))
+		}
+	case "down":
+		reverted, err := migrator.Down(*steps)
+		if err != nil {
+			logger.Fatal("migrate down failed", zap.Error(err))
+		}
+		logger.Info("migrate down done", zap.Int("reverted", reverted))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			logger.Fatal("migrate status failed", zap.Error(err))
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -29,4 +29,11 @@ VALUES
 ((select id from public.user where email = 'fiona.gallagher@example.com'), 33),
 ((select id from public.user where email = 'george.costanza@example.com'), 93),
 ((select id from public.user where email = 'hannah.baker@example.com'), 13),
-((select id from public.user where email = 'ian.malcolm@example.com'), 73);
\ No newline at end of file
+((select id from public.user where email = 'ian.malcolm@example.com'), 73);
+
+-- Opening balances, so the ledger explains every balance that existed before it did.
+INSERT INTO point_transactions (user_id, delta, balance_after, reason, actor)
+SELECT p.user_id, COALESCE(p.points, 0), COALESCE(p.points, 0), 'opening_balance', 'system'
+FROM points p
+WHERE p.user_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM point_transactions t WHERE t.user_id = p.user_id);
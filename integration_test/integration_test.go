@@ -15,8 +15,10 @@ import (
 
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/auth"
 	"github.com/syedomair/backend-microservices/lib/container"
 	"github.com/syedomair/backend-microservices/lib/mockgrpc"
+	"github.com/syedomair/backend-microservices/lib/router"
 	"github.com/syedomair/backend-microservices/service/department_service/department"
 	"github.com/syedomair/backend-microservices/service/user_service/user"
 	"github.com/testcontainers/testcontainers-go"
@@ -86,37 +88,24 @@ func setupTestDB(t *testing.T) container.Container {
 		t.Fatal("Failed to create test database:", err)
 	}
 
-	// Connect to the new test database
-	testConnStr := fmt.Sprintf(
-		"%s dbname=%s",
-		connStr,
-		dbName,
-	)
-	testDB, err := sql.Open("postgres", testConnStr)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// Run migrations
-	if err := runMigrations(testDB); err != nil {
-		t.Fatal("Failed to run migrations:", err)
-	}
-
 	connecStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), dbName)
 
 	c, err := container.New(map[string]string{
-		container.LogLevel:      "DEBUG",
-		container.DatabaseURL:   connecStr,
-		container.Port:          "8186",
-		container.DBMaxIdle:     "10",
-		container.DBMaxOpen:     "100",
-		container.DBMaxLifeTime: "1",
-		container.DBMaxIdleTime: "10",
-		container.ZapConf:       "../config/zap-logger-config.json",
-		container.GormConf:      "../config/gorm-logger-config.json",
-		container.PprofEnable:   "false",
-		container.PointSrvcAddr: "point_service",
-		container.PointSrvcMax:  "10",
+		container.LogLevel:       "DEBUG",
+		container.DatabaseURL:    connecStr,
+		container.DB:             container.Postgres,
+		container.Port:           "8186",
+		container.DBMaxIdle:      "10",
+		container.DBMaxOpen:      "100",
+		container.DBMaxLifeTime:  "1",
+		container.DBMaxIdleTime:  "10",
+		container.ZapConf:        "../config/zap-logger-config.json",
+		container.GormConf:       "../config/gorm-logger-config.json",
+		container.PprofEnable:    "false",
+		container.PointSrvcAddr:  "point_service",
+		container.PointSrvcMax:   "10",
+		container.MigrateOnStart: "true",
+		container.DBInitScript:   "../database/data_script.sql",
 	})
 	if err != nil {
 		defer func() {
@@ -125,21 +114,9 @@ func setupTestDB(t *testing.T) container.Container {
 		panic("server initialization failed")
 	}
 
-	defer testDB.Close()
-
 	return c
 }
 
-func runMigrations(db *sql.DB) error {
-	// Read and execute your schema.sql
-	schema, err := os.ReadFile("../database/migration.sql")
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec(string(schema))
-	return err
-}
-
 func randString(n int) string {
 	b := make([]byte, n)
 	rand.Read(b)
@@ -152,10 +129,9 @@ func TestDepartmentAPI(t *testing.T) {
 
 	limit := 10
 	offset := 0
-	orderby := "name"
-	sort := "asc"
+	orderby := "name ASC"
 
-	departmentDB, count, err := departmentRepo.GetAllDepartmentDB(limit, offset, orderby, sort)
+	departmentDB, count, err := departmentRepo.GetAllDepartmentDB(context.Background(), limit, offset, orderby, nil, nil)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -199,10 +175,9 @@ func TestUserAPI(t *testing.T) {
 
 	limit := 10
 	offset := 0
-	orderby := "name"
-	sort := "asc"
+	orderby := "name ASC"
 
-	usersDB, count, err := userRepo.GetAllUserDB(limit, offset, orderby, sort)
+	usersDB, count, err := userRepo.GetAllUserDB(context.Background(), limit, offset, orderby, nil, nil)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -231,6 +206,7 @@ func TestUserAPI(t *testing.T) {
 
 	req, err := http.NewRequest("GET", "/users", nil)
 	assert.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, auth.Permissions{auth.PermUsersReadSalary: {}}))
 
 	rr := httptest.NewRecorder()
 
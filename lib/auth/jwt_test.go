@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("test-secret")
+
+func validClaims() *Claims {
+	return &Claims{
+		Subject:   "user-1",
+		Roles:     []string{"admin"},
+		Tenant:    "acme",
+		Issuer:    "issuer",
+		Audience:  Audience{"api"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := NewKeySet()
+	keys.AddHMAC("", secret)
+	keys.AddRSA("rsa-1", &rsaKey.PublicKey)
+	verifier := NewVerifier(keys, "issuer", "api")
+
+	sign := func(claims *Claims, alg, kid string, key interface{}) string {
+		token, err := Sign(claims, alg, kid, key)
+		require.NoError(t, err)
+		return token
+	}
+	expired := validClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = 0
+	wrongAudience := validClaims()
+	wrongAudience.Audience = Audience{"other"}
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "other"
+	notYetValid := validClaims()
+	notYetValid.NotBefore = time.Now().Add(time.Hour).Unix()
+
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","exp":9999999999}`)) + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "HS256", token: sign(validClaims(), HS256, "", secret)},
+		{name: "RS256", token: sign(validClaims(), RS256, "rsa-1", rsaKey)},
+		{name: "Wrong hmac secret", token: sign(validClaims(), HS256, "", []byte("other")), wantErr: ErrInvalidToken},
+		{name: "Wrong rsa key", token: sign(validClaims(), RS256, "rsa-1", otherRSAKey), wantErr: ErrInvalidToken},
+		{name: "Unknown rsa kid", token: sign(validClaims(), RS256, "rsa-2", rsaKey), wantErr: ErrUnknownKey},
+		{name: "Alg none", token: unsigned, wantErr: ErrInvalidToken},
+		{name: "Expired", token: sign(expired, HS256, "", secret), wantErr: ErrTokenExpired},
+		{name: "Missing exp", token: sign(noExpiry, HS256, "", secret), wantErr: ErrInvalidToken},
+		{name: "Not yet valid", token: sign(notYetValid, HS256, "", secret), wantErr: ErrInvalidToken},
+		{name: "Wrong audience", token: sign(wrongAudience, HS256, "", secret), wantErr: ErrInvalidToken},
+		{name: "Wrong issuer", token: sign(wrongIssuer, HS256, "", secret), wantErr: ErrInvalidToken},
+		{name: "Malformed", token: "abc.def", wantErr: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, claims)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.Equal(t, "acme", claims.Tenant)
+			assert.True(t, claims.HasRole("admin"))
+			assert.False(t, claims.HasRole("viewer"))
+		})
+	}
+}
+
+func TestVerifier_RejectsPublicKeyAsHMACSecret(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := NewKeySet()
+	keys.AddRSA("", &rsaKey.PublicKey)
+
+	publicKeyBytes := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	token, err := Sign(validClaims(), HS256, "", publicKeyBytes)
+	require.NoError(t, err)
+
+	_, err = NewVerifier(keys, "", "").Verify(token)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestKeySet_LoadFiles(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pemPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{
+			"kty": "RSA",
+			"kid": "rsa-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{"kty": "oct", "kid": "hmac-1", "k": base64.RawURLEncoding.EncodeToString(secret)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc"},
+	}})
+	require.NoError(t, err)
+	jwksPath := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, jwks, 0o600))
+
+	pemKeys := NewKeySet()
+	require.NoError(t, pemKeys.LoadRSAPublicKeyFile(pemPath))
+	token, err := Sign(validClaims(), RS256, "", rsaKey)
+	require.NoError(t, err)
+	_, err = NewVerifier(pemKeys, "", "").Verify(token)
+	assert.NoError(t, err)
+
+	jwksKeys := NewKeySet()
+	require.NoError(t, jwksKeys.LoadJWKSFile(jwksPath))
+	assert.Equal(t, 2, jwksKeys.Len())
+	for kid, key := range map[string]interface{}{"rsa-1": rsaKey, "hmac-1": secret} {
+		alg := RS256
+		if kid == "hmac-1" {
+			alg = HS256
+		}
+		token, err := Sign(validClaims(), alg, kid, key)
+		require.NoError(t, err)
+		_, err = NewVerifier(jwksKeys, "", "").Verify(token)
+		assert.NoError(t, err, kid)
+	}
+
+	assert.Error(t, NewKeySet().LoadJWKSFile(filepath.Join(dir, "missing.json")))
+	assert.Error(t, NewKeySet().LoadRSAPublicKeyFile(jwksPath))
+}
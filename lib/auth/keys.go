@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet holds the keys tokens may be signed with, by key id. Keys added without an id
+// verify tokens that carry no kid or a kid the set does not know.
+type KeySet struct {
+	hmac map[string][]byte
+	rsa  map[string]*rsa.PublicKey
+}
+
+// NewKeySet returns an empty KeySet.
+func NewKeySet() *KeySet {
+	return &KeySet{hmac: map[string][]byte{}, rsa: map[string]*rsa.PublicKey{}}
+}
+
+// AddHMAC adds an HS256 secret.
+func (k *KeySet) AddHMAC(kid string, secret []byte) {
+	k.hmac[kid] = secret
+}
+
+// AddRSA adds an RS256 public key.
+func (k *KeySet) AddRSA(kid string, key *rsa.PublicKey) {
+	k.rsa[kid] = key
+}
+
+// Len returns the number of keys.
+func (k *KeySet) Len() int {
+	return len(k.hmac) + len(k.rsa)
+}
+
+func (k *KeySet) hmacKey(kid string) ([]byte, bool) {
+	if secret, ok := k.hmac[kid]; ok {
+		return secret, true
+	}
+	secret, ok := k.hmac[""]
+	return secret, ok
+}
+
+func (k *KeySet) rsaKey(kid string) (*rsa.PublicKey, bool) {
+	if key, ok := k.rsa[kid]; ok {
+		return key, true
+	}
+	key, ok := k.rsa[""]
+	return key, ok
+}
+
+// LoadRSAPublicKeyFile adds the PEM encoded RSA public key at path, without a key id.
+func (k *KeySet) LoadRSAPublicKeyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read rsa public key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("failed to decode rsa public key: no PEM block")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse rsa public key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("public key is %T, not RSA", parsed)
+	}
+	k.AddRSA("", key)
+	return nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadJWKSFile adds the RSA and oct (HMAC) keys of the JSON Web Key Set at path.
+// Keys with "use" other than "sig" and other key types are skipped.
+func (k *KeySet) LoadJWKSFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read jwks: %v", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to parse jwks: %v", err)
+	}
+
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		switch key.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				return fmt.Errorf("jwks key %q: invalid n", key.Kid)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				return fmt.Errorf("jwks key %q: invalid e", key.Kid)
+			}
+			k.AddRSA(key.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())})
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return fmt.Errorf("jwks key %q: invalid k", key.Kid)
+			}
+			k.AddHMAC(key.Kid, secret)
+		}
+	}
+	return nil
+}
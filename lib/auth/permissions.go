@@ -0,0 +1,54 @@
+package auth
+
+// Permissions required by the HTTP endpoints.
+const (
+	PermUsersRead        = "users:read"
+	PermUsersWrite       = "users:write"
+	PermUsersReadSalary  = "users:read_salary"
+	PermDepartmentsRead  = "departments:read"
+	PermDepartmentsWrite = "departments:write"
+
+	// AllPermissions grants every permission.
+	AllPermissions = "*"
+)
+
+// RolePermissions maps the roles in a token to the permissions they grant.
+type RolePermissions map[string][]string
+
+// DefaultRolePermissions lets admins do everything, HR manage users including salaries,
+// and viewers read users and departments without salaries.
+var DefaultRolePermissions = RolePermissions{
+	"admin":  {AllPermissions},
+	"hr":     {PermUsersRead, PermUsersWrite, PermUsersReadSalary, PermDepartmentsRead},
+	"viewer": {PermUsersRead, PermDepartmentsRead},
+}
+
+// Grants returns the permissions granted to claims. A role named after a permission,
+// such as "users:read", grants that permission directly; only a mapped role grants AllPermissions.
+func (rp RolePermissions) Grants(claims *Claims) Permissions {
+	granted := Permissions{}
+	if claims == nil {
+		return granted
+	}
+	for _, role := range claims.Roles {
+		if role != AllPermissions {
+			granted[role] = struct{}{}
+		}
+		for _, permission := range rp[role] {
+			granted[permission] = struct{}{}
+		}
+	}
+	return granted
+}
+
+// Permissions is a set of granted permissions.
+type Permissions map[string]struct{}
+
+// Has reports whether permission, or AllPermissions, was granted.
+func (p Permissions) Has(permission string) bool {
+	if _, ok := p[AllPermissions]; ok {
+		return true
+	}
+	_, ok := p[permission]
+	return ok
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePermissions_Grants(t *testing.T) {
+	rolePermissions := RolePermissions{
+		"admin":  {AllPermissions},
+		"viewer": {PermUsersRead},
+	}
+
+	tests := []struct {
+		name    string
+		claims  *Claims
+		allowed []string
+		denied  []string
+	}{
+		{name: "Nil claims", claims: nil, denied: []string{PermUsersRead}},
+		{name: "Mapped role", claims: &Claims{Roles: []string{"viewer"}}, allowed: []string{PermUsersRead}, denied: []string{PermUsersWrite, PermUsersReadSalary}},
+		{name: "Wildcard role", claims: &Claims{Roles: []string{"admin"}}, allowed: []string{PermUsersRead, PermUsersReadSalary, "anything"}},
+		{name: "Permission as role", claims: &Claims{Roles: []string{"viewer", PermUsersReadSalary}}, allowed: []string{PermUsersRead, PermUsersReadSalary}, denied: []string{PermUsersWrite}},
+		{name: "Unmapped wildcard", claims: &Claims{Roles: []string{AllPermissions}}, denied: []string{PermUsersRead}},
+		{name: "Unknown role", claims: &Claims{Roles: []string{"intern"}}, denied: []string{PermUsersRead}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granted := rolePermissions.Grants(tt.claims)
+			for _, permission := range tt.allowed {
+				assert.True(t, granted.Has(permission), permission)
+			}
+			for _, permission := range tt.denied {
+				assert.False(t, granted.Has(permission), permission)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Package breaker provides a circuit breaker for calls to other services.
+//
+// The breaker starts closed. After FailureThreshold consecutive failures it opens and rejects calls
+// with ErrOpen for OpenTimeout, then lets HalfOpenRequests trial calls through. A successful trial
+// closes it again; a failed one reopens it. Calls abandoned by their caller, which return
+// context.Canceled, count as neither.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/syedomair/backend-microservices/lib/metrics"
+)
+
+// ErrOpen is returned by Execute while the breaker rejects calls.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State of a Breaker.
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+var (
+	breakerState = metrics.MustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state: 0 closed, 1 half open, 2 open",
+		},
+		[]string{"name"},
+	))
+
+	breakerTransitions = metrics.MustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "Circuit breaker state changes",
+		},
+		[]string{"name", "to"},
+	))
+)
+
+// Settings configures a Breaker. Zero values fall back to the defaults below.
+type Settings struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenRequests int
+}
+
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+// Breaker is safe for concurrent use.
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	inFlight int
+}
+
+// New returns a closed breaker. name labels its metrics.
+func New(name string, settings Settings) *Breaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = DefaultFailureThreshold
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = DefaultOpenTimeout
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+	b := &Breaker{name: name, settings: settings, now: time.Now}
+	breakerState.WithLabelValues(name).Set(float64(StateClosed))
+	return b
+}
+
+// State returns the current state, moving an expired open breaker to half open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Execute runs fn unless the breaker is open, and records its outcome.
+// A nil Breaker runs fn directly.
+func (b *Breaker) Execute(fn func() error) error {
+	if b == nil {
+		return fn()
+	}
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	if errors.Is(err, context.Canceled) {
+		b.release()
+		return err
+	}
+	b.record(err == nil)
+	return err
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+
+	switch b.state {
+	case StateOpen:
+		return ErrOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.settings.HalfOpenRequests {
+			return ErrOpen
+		}
+	}
+	b.inFlight++
+	return nil
+}
+
+// release ends a call without recording an outcome.
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inFlight--
+}
+
+func (b *Breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inFlight--
+
+	if success {
+		b.failures = 0
+		if b.state == StateHalfOpen {
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.settings.FailureThreshold {
+		b.openedAt = b.now()
+		b.setState(StateOpen)
+	}
+}
+
+// refresh moves an open breaker whose timeout has passed to half open. The caller holds b.mu.
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+// setState changes the state and publishes it. The caller holds b.mu.
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if state != StateOpen {
+		b.failures = 0
+	}
+	breakerState.WithLabelValues(b.name).Set(float64(state))
+	breakerTransitions.WithLabelValues(b.name, state.String()).Inc()
+}
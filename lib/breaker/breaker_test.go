@@ -0,0 +1,101 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errCall = errors.New("call failed")
+
+func newTestBreaker(settings Settings) (*Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New("test", settings)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(Settings{FailureThreshold: 2, OpenTimeout: time.Minute})
+
+	assert.ErrorIs(t, b.Execute(func() error { return errCall }), errCall)
+	assert.Equal(t, StateClosed, b.State())
+	assert.ErrorIs(t, b.Execute(func() error { return errCall }), errCall)
+	assert.Equal(t, StateOpen, b.State())
+
+	called := false
+	err := b.Execute(func() error { called = true; return nil })
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.False(t, called)
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(Settings{FailureThreshold: 2})
+
+	_ = b.Execute(func() error { return errCall })
+	_ = b.Execute(func() error { return nil })
+	_ = b.Execute(func() error { return errCall })
+
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		trial error
+		want  State
+	}{
+		{name: "Successful trial closes", trial: nil, want: StateClosed},
+		{name: "Failed trial reopens", trial: errCall, want: StateOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := newTestBreaker(Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+			_ = b.Execute(func() error { return errCall })
+			assert.Equal(t, StateOpen, b.State())
+
+			*now = now.Add(time.Minute)
+			assert.Equal(t, StateHalfOpen, b.State())
+
+			_ = b.Execute(func() error { return tt.trial })
+			assert.Equal(t, tt.want, b.State())
+		})
+	}
+}
+
+func TestBreaker_HalfOpenLimitsTrialCalls(t *testing.T) {
+	b, now := newTestBreaker(Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+	_ = b.Execute(func() error { return errCall })
+	*now = now.Add(time.Minute)
+
+	err := b.Execute(func() error {
+		// A second caller is rejected while the trial call is in flight.
+		assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrOpen)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_IgnoresCanceledCalls(t *testing.T) {
+	b, now := newTestBreaker(Settings{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+
+	assert.ErrorIs(t, b.Execute(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, StateClosed, b.State())
+
+	_ = b.Execute(func() error { return errCall })
+	*now = now.Add(time.Minute)
+	_ = b.Execute(func() error { return context.Canceled })
+	assert.Equal(t, StateHalfOpen, b.State())
+	// The canceled trial gave its slot back.
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_Nil(t *testing.T) {
+	var b *Breaker
+	assert.ErrorIs(t, b.Execute(func() error { return errCall }), errCall)
+}
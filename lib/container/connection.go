@@ -1,15 +1,80 @@
 package container
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/syedomair/backend-microservices/lib/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultAcquireTimeout bounds how long Get waits for a connection once every connection is checked out.
+const DefaultAcquireTimeout = 5 * time.Second
+
+// DefaultHealthCheckTimeout bounds each health probe made by a pool created WithHealthCheck.
+const DefaultHealthCheckTimeout = time.Second
+
+var (
+	// ErrPoolExhausted is returned when no connection became free before the caller's deadline.
+	ErrPoolExhausted = errors.New("connection pool exhausted")
+	// ErrPoolClosed is returned by Get and GetContext after Close.
+	ErrPoolClosed = errors.New("connection pool closed")
+)
+
+var (
+	poolActiveConnections = metrics.MustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "grpc_pool_active_connections",
+			Help: "Connections currently checked out of the pool",
+		},
+		[]string{"target"},
+	))
+
+	poolIdleConnections = metrics.MustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "grpc_pool_idle_connections",
+			Help: "Connections waiting in the pool to be reused",
+		},
+		[]string{"target"},
+	))
+
+	poolWaiters = metrics.MustRegister(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "grpc_pool_waiters",
+			Help: "Callers blocked waiting for a connection",
+		},
+		[]string{"target"},
+	))
+
+	poolWaitDuration = metrics.MustRegister(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_pool_wait_seconds",
+			Help:    "Time spent acquiring a connection from the pool",
+			Buckets: []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 2.5, 5},
+		},
+		[]string{"target", "result"},
+	))
+
+	poolEvictions = metrics.MustRegister(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_pool_evictions_total",
+			Help: "Connections closed by the pool instead of being reused",
+		},
+		[]string{"target", "reason"},
+	))
 )
 
 type ConnectionPoolInterface interface {
 	Get() (*grpc.ClientConn, error)
+	GetContext(ctx context.Context) (*grpc.ClientConn, error)
 	Put(conn *grpc.ClientConn)
 	Close()
 }
@@ -20,59 +85,255 @@ type ConnectionPool struct {
 	connections chan *grpc.ClientConn
 	mu          sync.Mutex
 	active      int
+	closed      bool
+	createdAt   map[*grpc.ClientConn]time.Time
+	// released is closed and replaced whenever a slot is freed, waking callers blocked in wait.
+	released chan struct{}
+
+	maxAge             time.Duration
+	healthCheck        bool
+	healthService      string
+	healthCheckTimeout time.Duration
+	dialOptions        []grpc.DialOption
+}
+
+var _ ConnectionPoolInterface = (*ConnectionPool)(nil)
+
+// PoolOption configures a ConnectionPool.
+type PoolOption func(*ConnectionPool)
+
+// WithMaxAge closes connections older than maxAge instead of handing them out again. Zero keeps them forever.
+func WithMaxAge(maxAge time.Duration) PoolOption {
+	return func(p *ConnectionPool) {
+		p.maxAge = maxAge
+	}
+}
+
+// WithHealthCheck probes idle connections with the standard gRPC health service before reusing them.
+// An empty service asks about the server as a whole.
+func WithHealthCheck(service string, timeout time.Duration) PoolOption {
+	return func(p *ConnectionPool) {
+		p.healthCheck = true
+		p.healthService = service
+		p.healthCheckTimeout = timeout
+	}
 }
 
-func NewConnectionPool(target string, maxSize int) (*ConnectionPool, error) {
+// WithDialOptions adds options used when the pool dials a new connection.
+func WithDialOptions(opts ...grpc.DialOption) PoolOption {
+	return func(p *ConnectionPool) {
+		p.dialOptions = append(p.dialOptions, opts...)
+	}
+}
+
+func NewConnectionPool(target string, maxSize int, opts ...PoolOption) (*ConnectionPool, error) {
 	if maxSize <= 0 {
 		return nil, fmt.Errorf("maxSize must be greater than 0")
 	}
 	pool := &ConnectionPool{
-		target:      target,
-		maxSize:     maxSize,
-		connections: make(chan *grpc.ClientConn, maxSize),
-		active:      0,
+		target:             target,
+		maxSize:            maxSize,
+		connections:        make(chan *grpc.ClientConn, maxSize),
+		active:             0,
+		createdAt:          make(map[*grpc.ClientConn]time.Time),
+		released:           make(chan struct{}),
+		healthCheckTimeout: DefaultHealthCheckTimeout,
+		// The stats handler traces each call and sends its W3C trace context to the server.
+		dialOptions: []grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		},
+	}
+	for _, opt := range opts {
+		opt(pool)
+	}
+	if pool.maxAge < 0 {
+		return nil, fmt.Errorf("maxAge must not be negative")
 	}
 	return pool, nil
 }
 
+// Get returns a connection, waiting at most DefaultAcquireTimeout for one to be returned when the pool is exhausted.
 func (p *ConnectionPool) Get() (*grpc.ClientConn, error) {
-	select {
-	case conn := <-p.connections:
-		return conn, nil
-	default:
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultAcquireTimeout)
+	defer cancel()
+	return p.GetContext(ctx)
+}
+
+// GetContext returns an idle connection, dials a new one while the pool is below maxSize,
+// and otherwise waits for a connection to be returned or a slot to be freed until ctx is done.
+// Idle connections that are broken, too old or unhealthy are closed and replaced.
+func (p *ConnectionPool) GetContext(ctx context.Context) (*grpc.ClientConn, error) {
+	start := time.Now()
+
+	for {
+		select {
+		case conn, ok := <-p.connections:
+			if !ok {
+				return nil, ErrPoolClosed
+			}
+			if p.reusable(conn) {
+				return p.acquired(conn, start)
+			}
+			continue
+		default:
+		}
+
 		p.mu.Lock()
-		defer p.mu.Unlock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
 		if p.active < p.maxSize {
-			conn, err := grpc.NewClient(p.target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			conn, err := grpc.NewClient(p.target, p.dialOptions...)
 			if err != nil {
+				p.mu.Unlock()
 				return nil, fmt.Errorf("failed to dial: %v", err)
 			}
 			p.active++
-			return conn, nil
+			p.createdAt[conn] = time.Now()
+			p.mu.Unlock()
+			return p.acquired(conn, start)
+		}
+		released := p.released
+		p.mu.Unlock()
+
+		conn, err := p.wait(ctx, start, released)
+		if err != nil {
+			return nil, err
+		}
+		// A nil conn means a slot was freed; loop back to dial a replacement.
+		if conn != nil && p.reusable(conn) {
+			return p.acquired(conn, start)
 		}
-		// If max size is reached, wait for a connection to be returned.
-		return <-p.connections, nil
 	}
 }
 
-func (p *ConnectionPool) Put(conn *grpc.ClientConn) {
+// wait blocks until a connection is returned to the pool, released is closed or ctx is done.
+// It returns a nil connection when released is closed.
+func (p *ConnectionPool) wait(ctx context.Context, start time.Time, released <-chan struct{}) (*grpc.ClientConn, error) {
+	waiters := poolWaiters.WithLabelValues(p.target)
+	waiters.Inc()
+	defer waiters.Dec()
+
 	select {
-	case p.connections <- conn:
-	default:
-		// Pool is full, close the connection.
-		conn.Close()
+	case conn, ok := <-p.connections:
+		if !ok {
+			return nil, ErrPoolClosed
+		}
+		return conn, nil
+	case <-released:
+		return nil, nil
+	case <-ctx.Done():
+		poolWaitDuration.WithLabelValues(p.target, "exhausted").Observe(time.Since(start).Seconds())
+		return nil, fmt.Errorf("%w: %d connections to %s in use after %s: %w",
+			ErrPoolExhausted, p.maxSize, p.target, time.Since(start).Round(time.Millisecond), ctx.Err())
+	}
+}
+
+// reusable reports whether an idle conn may be handed out, evicting it when it may not.
+func (p *ConnectionPool) reusable(conn *grpc.ClientConn) bool {
+	if reason := p.evictionReason(conn); reason != "" {
+		p.evict(conn, reason)
+		return false
+	}
+	if p.healthCheck && !p.healthy(conn) {
+		p.evict(conn, "unhealthy")
+		return false
+	}
+	return true
+}
+
+// evictionReason returns why conn must not be reused, or "" when it can be.
+func (p *ConnectionPool) evictionReason(conn *grpc.ClientConn) string {
+	switch conn.GetState() {
+	case connectivity.TransientFailure:
+		return "transient_failure"
+	case connectivity.Shutdown:
+		return "shutdown"
+	}
+	if p.maxAge > 0 {
 		p.mu.Lock()
-		p.active--
+		createdAt, ok := p.createdAt[conn]
 		p.mu.Unlock()
+		if ok && time.Since(createdAt) > p.maxAge {
+			return "max_age"
+		}
+	}
+	return ""
+}
+
+// healthy probes conn on its own context, so a caller that gives up does not get a healthy connection evicted.
+func (p *ConnectionPool) healthy(conn *grpc.ClientConn) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), p.healthCheckTimeout)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.healthService})
+	return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+}
+
+// evict closes conn and frees its slot so that a replacement can be dialled.
+func (p *ConnectionPool) evict(conn *grpc.ClientConn, reason string) {
+	conn.Close()
+	poolEvictions.WithLabelValues(p.target, reason).Inc()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.forget(conn)
+	poolIdleConnections.WithLabelValues(p.target).Set(float64(len(p.connections)))
+}
+
+// forget releases the slot held by conn and wakes the waiters so one of them can dial into it.
+// The caller holds p.mu.
+func (p *ConnectionPool) forget(conn *grpc.ClientConn) {
+	delete(p.createdAt, conn)
+	p.active--
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+func (p *ConnectionPool) acquired(conn *grpc.ClientConn, start time.Time) (*grpc.ClientConn, error) {
+	poolWaitDuration.WithLabelValues(p.target, "acquired").Observe(time.Since(start).Seconds())
+	poolActiveConnections.WithLabelValues(p.target).Inc()
+	poolIdleConnections.WithLabelValues(p.target).Set(float64(len(p.connections)))
+	return conn, nil
+}
+
+// Put returns conn to the pool. Broken or expired connections are closed instead.
+func (p *ConnectionPool) Put(conn *grpc.ClientConn) {
+	poolActiveConnections.WithLabelValues(p.target).Dec()
+
+	if reason := p.evictionReason(conn); reason != "" {
+		p.evict(conn, reason)
+		return
 	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		select {
+		case p.connections <- conn:
+			poolIdleConnections.WithLabelValues(p.target).Set(float64(len(p.connections)))
+			return
+		default:
+		}
+	}
+	// Pool is full or closed, close the connection.
+	conn.Close()
+	p.forget(conn)
 }
 
 func (p *ConnectionPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
 	close(p.connections)
 	for conn := range p.connections {
 		conn.Close()
-		p.mu.Lock()
-		p.active--
-		p.mu.Unlock()
+		p.forget(conn)
 	}
+	poolIdleConnections.WithLabelValues(p.target).Set(0)
 }
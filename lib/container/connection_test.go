@@ -2,11 +2,17 @@ package container
 
 import (
 	"context"
+	"errors"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -79,3 +85,190 @@ func TestConnectionPool_Close(t *testing.T) {
 	pool.Close()
 	assert.Equal(t, 0, pool.active)
 }
+
+func TestConnectionPool_GetContextExhausted(t *testing.T) {
+	startBufconnServer()
+	pool, _ := NewConnectionPool("bufnet", 1)
+
+	conn1, err := pool.GetContext(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	conn2, err := pool.GetContext(ctx)
+	assert.Nil(t, conn2)
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pool.Put(conn1)
+	conn3, err := pool.GetContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, conn1, conn3)
+	pool.Put(conn3)
+}
+
+func TestConnectionPool_GetContextCanceledWhileWaiting(t *testing.T) {
+	startBufconnServer()
+	pool, _ := NewConnectionPool("bufnet", 1)
+	conn1, _ := pool.Get()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := pool.GetContext(ctx)
+		errs <- err
+	}()
+	cancel()
+
+	err := <-errs
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+	assert.ErrorIs(t, err, context.Canceled)
+	pool.Put(conn1)
+}
+
+func TestConnectionPool_GetAfterClose(t *testing.T) {
+	startBufconnServer()
+	pool, _ := NewConnectionPool("bufnet", 2)
+	conn1, _ := pool.Get()
+	pool.Close()
+
+	_, err := pool.Get()
+	assert.ErrorIs(t, err, ErrPoolClosed)
+
+	// Returning a connection after Close closes it instead of panicking.
+	pool.Put(conn1)
+	assert.Equal(t, 0, pool.active)
+}
+
+func TestConnectionPool_PutEvictsShutdownConnection(t *testing.T) {
+	pool, _ := NewConnectionPool("bufnet", 2)
+	conn1, _ := pool.Get()
+	conn1.Close()
+	pool.Put(conn1)
+	assert.Equal(t, 0, pool.active)
+
+	conn2, err := pool.Get()
+	assert.NoError(t, err)
+	assert.NotEqual(t, conn1, conn2, "Expected a closed connection to be replaced")
+	pool.Put(conn2)
+}
+
+func TestConnectionPool_WaiterDialsIntoEvictedSlot(t *testing.T) {
+	// Every dial fails, as it does while the server restarts, so connections end up in TransientFailure.
+	const target = "passthrough:///restarting"
+	pool, _ := NewConnectionPool(target, 1,
+		WithDialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		})))
+
+	conn1, err := pool.Get()
+	assert.NoError(t, err)
+	conn1.Connect()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for state := conn1.GetState(); state != connectivity.TransientFailure; state = conn1.GetState() {
+		if !conn1.WaitForStateChange(ctx, state) {
+			t.Fatalf("connection stayed %v, want TransientFailure", state)
+		}
+	}
+
+	type result struct {
+		conn *grpc.ClientConn
+		err  error
+	}
+	results := make(chan result)
+	go func() {
+		conn, err := pool.Get()
+		results <- result{conn, err}
+	}()
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(poolWaiters.WithLabelValues(target)) == 1
+	}, time.Second, time.Millisecond)
+
+	pool.Put(conn1)
+
+	select {
+	case got := <-results:
+		assert.NoError(t, got.err)
+		assert.NotNil(t, got.conn)
+		assert.NotEqual(t, conn1, got.conn, "Expected the waiter to dial a new connection")
+		assert.Equal(t, connectivity.Shutdown, conn1.GetState())
+		assert.Equal(t, 1, pool.active)
+		pool.Put(got.conn)
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not woken when the broken connection was evicted")
+	}
+}
+
+func TestConnectionPool_MaxAge(t *testing.T) {
+	pool, _ := NewConnectionPool("bufnet", 2, WithMaxAge(time.Nanosecond))
+	conn1, _ := pool.Get()
+	time.Sleep(time.Millisecond)
+	pool.Put(conn1)
+	assert.Equal(t, 0, pool.active)
+
+	_, err := NewConnectionPool("bufnet", 2, WithMaxAge(-time.Second))
+	assert.Error(t, err)
+}
+
+func TestConnectionPool_HealthCheck(t *testing.T) {
+	listener := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	go s.Serve(listener)
+	defer s.Stop()
+
+	pool, _ := NewConnectionPool("passthrough:///bufnet", 2,
+		WithHealthCheck("", time.Second),
+		WithDialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		})))
+
+	conn1, err := pool.Get()
+	assert.NoError(t, err)
+	pool.Put(conn1)
+
+	// A serving server keeps its connection in the pool.
+	conn2, err := pool.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, conn1, conn2)
+	pool.Put(conn2)
+
+	// Once the server reports NOT_SERVING the idle connection is replaced.
+	healthServer.Shutdown()
+	conn3, err := pool.Get()
+	assert.NoError(t, err)
+	assert.NotEqual(t, conn1, conn3)
+	assert.Equal(t, connectivity.Shutdown, conn1.GetState())
+	assert.Equal(t, 1, pool.active)
+	pool.Put(conn3)
+}
+
+func TestConnectionPool_HealthCheckIgnoresCallerCancellation(t *testing.T) {
+	listener := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(listener)
+	defer s.Stop()
+
+	pool, _ := NewConnectionPool("passthrough:///bufnet", 1,
+		WithHealthCheck("", time.Second),
+		WithDialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		})))
+
+	conn1, err := pool.Get()
+	assert.NoError(t, err)
+	pool.Put(conn1)
+
+	// The caller gave up before asking; its idle connection is still healthy and must not be evicted.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	conn2, err := pool.GetContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, conn1, conn2)
+	assert.NotEqual(t, connectivity.Shutdown, conn1.GetState())
+	assert.Equal(t, 1, pool.active)
+	pool.Put(conn2)
+}
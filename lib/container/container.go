@@ -1,30 +1,70 @@
 package container
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"strconv"
-
+	"strings"
+	"time"
+
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/logging"
+	"github.com/syedomair/backend-microservices/lib/migrate"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/lib/retry"
+	"github.com/syedomair/backend-microservices/lib/tracing"
+	pb "github.com/syedomair/backend-microservices/proto/v1/point"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 const (
-	LogLevel      = "LOG_LEVEL"
-	DatabaseURL   = "DATABASE_URL"
-	Port          = "PORT"
-	DB            = "DB"
-	DBMaxIdle     = "DB_MAX_IDLE"
-	DBMaxOpen     = "DB_MAX_OPEN"
-	DBMaxLifeTime = "DB_MAX_LIFE_TIME"
-	DBMaxIdleTime = "DB_MAX_IDLE_TIME"
-	ZapConf       = "ZAP_CONF"
-	GormConf      = "GORM_CONF"
-	PprofEnable   = "PPROF_ENABLE"
-	PointSrvcAddr = "POINT_SRVC_ADDR"
-	PointSrvcMax  = "POINT_SRVC_MAX"
+	LogLevel             = "LOG_LEVEL"
+	DatabaseURL          = "DATABASE_URL"
+	Port                 = "PORT"
+	DB                   = "DB"
+	DBMaxIdle            = "DB_MAX_IDLE"
+	DBMaxOpen            = "DB_MAX_OPEN"
+	DBMaxLifeTime        = "DB_MAX_LIFE_TIME"
+	DBMaxIdleTime        = "DB_MAX_IDLE_TIME"
+	ZapConf              = "ZAP_CONF"
+	GormConf             = "GORM_CONF"
+	PprofEnable          = "PPROF_ENABLE"
+	PointSrvcAddr        = "POINT_SRVC_ADDR"
+	PointSrvcMax         = "POINT_SRVC_MAX"
+	DBInitScript         = "DB_INIT_SCRIPT"
+	MigrateOnStart       = "MIGRATE_ON_START"
+	PointSrvcMaxAge      = "POINT_SRVC_MAX_AGE"
+	PointSrvcHealthCheck = "POINT_SRVC_HEALTH_CHECK"
+
+	PointSrvcRetryMaxAttempts    = "POINT_SRVC_RETRY_MAX_ATTEMPTS"
+	PointSrvcRetryInitialBackoff = "POINT_SRVC_RETRY_INITIAL_BACKOFF"
+	PointSrvcRetryMaxBackoff     = "POINT_SRVC_RETRY_MAX_BACKOFF"
+	PointSrvcRetryAttemptTimeout = "POINT_SRVC_RETRY_ATTEMPT_TIMEOUT"
+	PointSrvcHedgingDelay        = "POINT_SRVC_HEDGING_DELAY"
+
+	AuthEnabled          = "AUTH_ENABLED"
+	AuthHMACSecret       = "AUTH_HMAC_SECRET"
+	AuthRSAPublicKeyFile = "AUTH_RSA_PUBLIC_KEY_FILE"
+	AuthJWKSFile         = "AUTH_JWKS_FILE"
+	AuthIssuer           = "AUTH_ISSUER"
+	AuthAudience         = "AUTH_AUDIENCE"
+
+	CursorSecret = "CURSOR_SECRET"
+
+	APIKeys = "API_KEYS"
+
+	TracingServiceName = "TRACING_SERVICE_NAME"
+	TracingExporter    = "TRACING_EXPORTER"
+	TracingFile        = "TRACING_FILE"
+	TracingEndpoint    = "TRACING_ENDPOINT"
+	TracingSampleRatio = "TRACING_SAMPLE_RATIO"
 
 	Postgres = "POSTGRES"
 	Mysql    = "MYSQL"
+	Sqlite   = "SQLITE"
 )
 
 // Container interface
@@ -34,6 +74,10 @@ type Container interface {
 	Port() string
 	PprofEnable() string
 	PointServicePool() ConnectionPoolInterface
+	Authenticator() *auth.Verifier
+	CursorCodec() *request.CursorCodec
+	APIKeys() []string
+	ShutdownTracing(ctx context.Context) error
 }
 
 type container struct {
@@ -43,6 +87,10 @@ type container struct {
 	pprofEnable          string
 	environmentVariables map[string]string
 	pointServicePool     ConnectionPoolInterface
+	authenticator        *auth.Verifier
+	cursorCodec          *request.CursorCodec
+	apiKeys              []string
+	shutdownTracing      tracing.ShutdownFunc
 }
 
 var _ Container = (*container)(nil)
@@ -55,9 +103,32 @@ func (c *container) PointServicePool() ConnectionPoolInterface {
 	return c.pointServicePool
 }
 
+// Authenticator returns the JWT verifier, or nil when AUTH_ENABLED is not true.
+func (c *container) Authenticator() *auth.Verifier {
+	return c.authenticator
+}
+
+func (c *container) CursorCodec() *request.CursorCodec {
+	return c.cursorCodec
+}
+
+// APIKeys returns the X-API-Key values listed in API_KEYS.
+func (c *container) APIKeys() []string {
+	return c.apiKeys
+}
+
+// ShutdownTracing flushes the spans not exported yet. Call it once the server has stopped.
+func (c *container) ShutdownTracing(ctx context.Context) error {
+	if c.shutdownTracing == nil {
+		return nil
+	}
+	return c.shutdownTracing(ctx)
+}
+
 func New(envVars map[string]string) (Container, error) {
 	requiredKeys := []string{
 		DatabaseURL,
+		DB,
 		Port,
 		ZapConf,
 		GormConf,
@@ -79,6 +150,11 @@ func New(envVars map[string]string) (Container, error) {
 	c := &container{environmentVariables: envVars}
 
 	var err error
+	// Tracing comes first so the database and the point_service pool are traced from the start.
+	c.shutdownTracing, err = c.tracingSetup()
+	if err != nil {
+		return c, err
+	}
 	c.db, err = c.dbSetup()
 	if err != nil {
 		return c, err
@@ -87,6 +163,9 @@ func New(envVars map[string]string) (Container, error) {
 	if err != nil {
 		return c, err
 	}
+	if err := c.schemaSetup(); err != nil {
+		return c, err
+	}
 	c.port, err = c.portSetup()
 	if err != nil {
 		return c, err
@@ -95,6 +174,15 @@ func New(envVars map[string]string) (Container, error) {
 	if err != nil {
 		return c, err
 	}
+	c.authenticator, err = c.authSetup()
+	if err != nil {
+		return c, err
+	}
+	c.cursorCodec, err = c.cursorSetup()
+	if err != nil {
+		return c, err
+	}
+	c.apiKeys = c.apiKeysSetup()
 
 	pointSrvcAddr, err := c.getRequiredEnvVar(PointSrvcAddr)
 	if err != nil {
@@ -106,7 +194,12 @@ func New(envVars map[string]string) (Container, error) {
 		return nil, err
 	}
 
-	c.pointServicePool, err = NewConnectionPool(pointSrvcAddr, pointSrvcMax)
+	poolOptions, err := c.pointServicePoolOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	c.pointServicePool, err = NewConnectionPool(pointSrvcAddr, pointSrvcMax, poolOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("did not connect error: %v", err)
 	}
@@ -149,7 +242,12 @@ func (c *container) dbSetup() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	ca := NewDBConnectionAdapter(DB,
+	dbName, err := c.getDBEnvVar()
+	if err != nil {
+		return nil, err
+	}
+
+	ca := NewDBConnectionAdapter(dbName,
 		strDatabaseURLEnvVar,
 		dbMaxIdle,
 		dbMaxOpen,
@@ -164,6 +262,198 @@ func (c *container) dbSetup() (*gorm.DB, error) {
 	return db, nil
 }
 
+// pointServicePoolOptions reads the optional POINT_SRVC_MAX_AGE, POINT_SRVC_HEALTH_CHECK and retry env vars.
+// Every call forwards the request ID of its context.
+func (c *container) pointServicePoolOptions() ([]PoolOption, error) {
+	var opts []PoolOption
+
+	maxAge, err := c.getDurationEnvVar(PointSrvcMaxAge, 0)
+	if err != nil {
+		return nil, err
+	}
+	if maxAge > 0 {
+		opts = append(opts, WithMaxAge(maxAge))
+	}
+
+	healthCheck, err := c.getBoolEnvVar(PointSrvcHealthCheck)
+	if err != nil {
+		return nil, err
+	}
+	if healthCheck {
+		opts = append(opts, WithHealthCheck("", DefaultHealthCheckTimeout))
+	}
+
+	policy, err := c.pointServiceRetryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithDialOptions(grpc.WithChainUnaryInterceptor(
+		logging.UnaryClientInterceptor(),
+		retry.UnaryClientInterceptor(policy),
+	)))
+	return opts, nil
+}
+
+// pointServiceRetryPolicy retries the read-only point_service methods. Mutations are never retried,
+// so a timed out AwardPoints cannot be applied twice.
+func (c *container) pointServiceRetryPolicy() (retry.Policy, error) {
+	policy := retry.DefaultPolicy(
+		pb.PointServer_GetUserPoints_FullMethodName,
+		pb.PointServer_GetUserListPoints_FullMethodName,
+		pb.PointServer_GetUserPointHistory_FullMethodName,
+		pb.PointServer_GetLeaderboard_FullMethodName,
+	)
+
+	var err error
+	if policy.MaxAttempts, err = c.getOptionalIntEnvVar(PointSrvcRetryMaxAttempts, policy.MaxAttempts); err != nil {
+		return policy, err
+	}
+	if policy.MaxAttempts < 1 {
+		return policy, fmt.Errorf("invalid envvar %q: must be at least 1", PointSrvcRetryMaxAttempts)
+	}
+	if policy.InitialBackoff, err = c.getDurationEnvVar(PointSrvcRetryInitialBackoff, policy.InitialBackoff); err != nil {
+		return policy, err
+	}
+	if policy.MaxBackoff, err = c.getDurationEnvVar(PointSrvcRetryMaxBackoff, policy.MaxBackoff); err != nil {
+		return policy, err
+	}
+	if policy.PerAttemptTimeout, err = c.getDurationEnvVar(PointSrvcRetryAttemptTimeout, policy.PerAttemptTimeout); err != nil {
+		return policy, err
+	}
+	if policy.HedgingDelay, err = c.getDurationEnvVar(PointSrvcHedgingDelay, policy.HedgingDelay); err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
+
+// getDBEnvVar returns the database type selected by the DB env var.
+func (c *container) getDBEnvVar() (string, error) {
+	strDB, err := c.getRequiredEnvVar(DB)
+	if err != nil {
+		return "", err
+	}
+	dbName := strings.ToUpper(strings.TrimSpace(strDB))
+	switch dbName {
+	case Postgres, Mysql, Sqlite:
+		return dbName, nil
+	}
+	return "", fmt.Errorf("invalid envvar %q value %q: must be %s, %s or %s", DB, strDB, Postgres, Mysql, Sqlite)
+}
+
+// schemaSetup applies pending migrations when MIGRATE_ON_START is true and then runs DB_INIT_SCRIPT.
+// The init script only runs against a database that had no schema yet, so restarting a service
+// does not seed the same rows twice. Without migrations that cannot be known, so DB_INIT_SCRIPT
+// requires MIGRATE_ON_START.
+func (c *container) schemaSetup() error {
+	migrateOnStart, err := c.getBoolEnvVar(MigrateOnStart)
+	if err != nil {
+		return err
+	}
+	initScript := c.environmentVariables[DBInitScript]
+	if initScript != "" && !migrateOnStart {
+		return fmt.Errorf("%s requires %s=true", DBInitScript, MigrateOnStart)
+	}
+
+	if migrateOnStart {
+		migrator, err := migrate.New(c.db, c.logger)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		// Up holds the migration lock, so only the service that built the schema from scratch applies every migration.
+		if applied != len(migrator.Migrations()) {
+			initScript = ""
+		}
+	}
+
+	if initScript != "" {
+		if err := RunSQLFile(c.db, initScript); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cursorSetup builds the codec of pagination cursors from CURSOR_SECRET. Without it a random key is
+// used, so cursors stop working when the service restarts and are not accepted by other replicas.
+func (c *container) cursorSetup() (*request.CursorCodec, error) {
+	secret := c.environmentVariables[CursorSecret]
+	if secret != "" {
+		return request.NewCursorCodec([]byte(secret)), nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate cursor key: %v", err)
+	}
+	c.logger.Warn("CURSOR_SECRET is not set, pagination cursors are only valid on this instance until it restarts")
+	return request.NewCursorCodec(key), nil
+}
+
+// apiKeysSetup reads API_KEYS, a comma separated list of the API keys clients may send in X-API-Key.
+func (c *container) apiKeysSetup() []string {
+	var keys []string
+	for _, key := range strings.Split(c.environmentVariables[APIKeys], ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// tracingSetup installs the tracer provider selected by TRACING_EXPORTER, which defaults to none.
+// TRACING_SAMPLE_RATIO, the fraction of new traces recorded, defaults to 1.
+func (c *container) tracingSetup() (tracing.ShutdownFunc, error) {
+	sampleRatio := 1.0
+	if strVal := c.environmentVariables[TracingSampleRatio]; strVal != "" {
+		var err error
+		if sampleRatio, err = strconv.ParseFloat(strVal, 64); err != nil {
+			return nil, fmt.Errorf("failed to convert %q to float: %w", strVal, err)
+		}
+	}
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: c.environmentVariables[TracingServiceName],
+		Exporter:    strings.ToLower(strings.TrimSpace(c.environmentVariables[TracingExporter])),
+		File:        c.environmentVariables[TracingFile],
+		Endpoint:    c.environmentVariables[TracingEndpoint],
+		SampleRatio: sampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing envvars: %w", err)
+	}
+	return shutdown, nil
+}
+
+// authSetup builds the JWT verifier from AUTH_HMAC_SECRET, AUTH_RSA_PUBLIC_KEY_FILE and AUTH_JWKS_FILE.
+// At least one key source is required once AUTH_ENABLED is true.
+func (c *container) authSetup() (*auth.Verifier, error) {
+	enabled, err := c.getBoolEnvVar(AuthEnabled)
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	keys := auth.NewKeySet()
+	if secret := c.environmentVariables[AuthHMACSecret]; secret != "" {
+		keys.AddHMAC("", []byte(secret))
+	}
+	if path := c.environmentVariables[AuthRSAPublicKeyFile]; path != "" {
+		if err := keys.LoadRSAPublicKeyFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if path := c.environmentVariables[AuthJWKSFile]; path != "" {
+		if err := keys.LoadJWKSFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if keys.Len() == 0 {
+		return nil, fmt.Errorf("envvar %q is true but none of %q, %q or %q provides a key", AuthEnabled, AuthHMACSecret, AuthRSAPublicKeyFile, AuthJWKSFile)
+	}
+	return auth.NewVerifier(keys, c.environmentVariables[AuthIssuer], c.environmentVariables[AuthAudience]), nil
+}
+
 func (c *container) loggerSetup() (*zap.Logger, error) {
 	if c.logger != nil {
 		return c.logger, nil
@@ -213,6 +503,43 @@ func (c *container) getIntEnvVar(key string) (int, error) {
 	return intVal, nil
 }
 
+// getOptionalIntEnvVar parses an optional int env var, returning def when it is unset.
+func (c *container) getOptionalIntEnvVar(key string, def int) (int, error) {
+	if c.environmentVariables[key] == "" {
+		return def, nil
+	}
+	return c.getIntEnvVar(key)
+}
+
+// getDurationEnvVar parses an optional duration env var such as "250ms", returning def when it is unset.
+func (c *container) getDurationEnvVar(key string, def time.Duration) (time.Duration, error) {
+	strVal := c.environmentVariables[key]
+	if strVal == "" {
+		return def, nil
+	}
+	duration, err := time.ParseDuration(strVal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert %q to duration: %w", strVal, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid envvar %q: must not be negative", key)
+	}
+	return duration, nil
+}
+
+// getBoolEnvVar parses an optional boolean env var, which defaults to false.
+func (c *container) getBoolEnvVar(key string) (bool, error) {
+	strVal := c.environmentVariables[key]
+	if strVal == "" {
+		return false, nil
+	}
+	boolVal, err := strconv.ParseBool(strVal)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert %q to bool: %w", strVal, err)
+	}
+	return boolVal, nil
+}
+
 func (c *container) getRequiredEnvVar(key string) (string, error) {
 	value, ok := c.environmentVariables[key]
 	if !ok {
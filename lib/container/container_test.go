@@ -1,11 +1,18 @@
 package container
 
 import (
+	"context"
 	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
 
+	pb "github.com/syedomair/backend-microservices/proto/v1/point"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -443,6 +450,40 @@ func Test_container_dbSetup(t *testing.T) {
 	}
 }
 
+func Test_container_getDBEnvVar(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    string
+		wantErr bool
+	}{
+		{name: "Postgres", value: "POSTGRES", set: true, want: Postgres},
+		{name: "MySQL lower case", value: " mysql ", set: true, want: Mysql},
+		{name: "SQLite", value: "SQLITE", set: true, want: Sqlite},
+		{name: "Unsupported value", value: "ORACLE", set: true, wantErr: true},
+		{name: "Empty value", value: "", set: true, wantErr: true},
+		{name: "Missing DB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envVars := map[string]string{}
+			if tt.set {
+				envVars[DB] = tt.value
+			}
+			c := &container{environmentVariables: envVars}
+			got, err := c.getDBEnvVar()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDBEnvVar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("getDBEnvVar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_container_loggerSetup(t *testing.T) {
 	type fields struct {
 		logger               *zap.Logger
@@ -602,3 +643,221 @@ func Test_container_pprofEnableSetup(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_SQLiteMigrateOnStart(t *testing.T) {
+	c, err := New(map[string]string{
+		DBMaxIdle:      "10",
+		DBMaxOpen:      "100",
+		DBMaxLifeTime:  "1",
+		DBMaxIdleTime:  "10",
+		DatabaseURL:    "file:containermigratetest?mode=memory",
+		DB:             "SQLITE",
+		DBInitScript:   "../../database/sqlite/data_script.sql",
+		MigrateOnStart: "true",
+		GormConf:       "../../config/gorm-logger-config.json",
+		ZapConf:        "../../config/zap-logger-config.json",
+		Port:           "8080",
+		PprofEnable:    "false",
+		PointSrvcAddr:  "point_service:8185",
+		PointSrvcMax:   "10",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var departments int64
+	if err := c.Db().Table("department").Count(&departments).Error; err != nil {
+		t.Fatalf("count departments error = %v", err)
+	}
+	if departments != 3 {
+		t.Errorf("departments = %d, want 3", departments)
+	}
+
+	var version int64
+	if err := c.Db().Table("schema_migrations").Select("MAX(version)").Scan(&version).Error; err != nil {
+		t.Fatalf("read schema version error = %v", err)
+	}
+	if version == 0 {
+		t.Errorf("schema version = 0, want migrations applied")
+	}
+}
+
+func TestNew_InvalidMigrateOnStart(t *testing.T) {
+	_, err := New(map[string]string{
+		DBMaxIdle:      "10",
+		DBMaxOpen:      "100",
+		DBMaxLifeTime:  "1",
+		DBMaxIdleTime:  "10",
+		DatabaseURL:    "file:containerinvalidtest?mode=memory",
+		DB:             "SQLITE",
+		MigrateOnStart: "sometimes",
+		GormConf:       "../../config/gorm-logger-config.json",
+		ZapConf:        "../../config/zap-logger-config.json",
+		Port:           "8080",
+		PprofEnable:    "false",
+		PointSrvcAddr:  "point_service:8185",
+		PointSrvcMax:   "10",
+	})
+	if err == nil {
+		t.Fatal("New() error = nil, want invalid MIGRATE_ON_START error")
+	}
+}
+
+func Test_container_pointServicePoolOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		envVars map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "Defaults", envVars: map[string]string{}, want: 1},
+		{name: "Max age and health check", envVars: map[string]string{PointSrvcMaxAge: "30m", PointSrvcHealthCheck: "true"}, want: 3},
+		{name: "Health check disabled", envVars: map[string]string{PointSrvcHealthCheck: "false"}, want: 1},
+		{name: "Invalid max age", envVars: map[string]string{PointSrvcMaxAge: "soon"}, wantErr: true},
+		{name: "Invalid retry attempts", envVars: map[string]string{PointSrvcRetryMaxAttempts: "0"}, wantErr: true},
+		{name: "Invalid hedging delay", envVars: map[string]string{PointSrvcHedgingDelay: "-1s"}, wantErr: true},
+		{name: "Invalid health check", envVars: map[string]string{PointSrvcHealthCheck: "maybe"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &container{environmentVariables: tt.envVars}
+			got, err := c.pointServicePoolOptions()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("pointServicePoolOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.want {
+				t.Errorf("pointServicePoolOptions() returned %d options, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_container_pointServiceRetryPolicy(t *testing.T) {
+	c := &container{environmentVariables: map[string]string{
+		PointSrvcRetryMaxAttempts:    "4",
+		PointSrvcRetryInitialBackoff: "50ms",
+		PointSrvcRetryMaxBackoff:     "2s",
+		PointSrvcRetryAttemptTimeout: "1s",
+		PointSrvcHedgingDelay:        "200ms",
+	}}
+
+	policy, err := c.pointServiceRetryPolicy()
+	if err != nil {
+		t.Fatalf("pointServiceRetryPolicy() error = %v", err)
+	}
+	if policy.MaxAttempts != 4 || policy.InitialBackoff != 50*time.Millisecond || policy.MaxBackoff != 2*time.Second ||
+		policy.PerAttemptTimeout != time.Second || policy.HedgingDelay != 200*time.Millisecond {
+		t.Errorf("pointServiceRetryPolicy() = %+v", policy)
+	}
+	for _, method := range []string{
+		pb.PointServer_AwardPoints_FullMethodName,
+		pb.PointServer_DeductPoints_FullMethodName,
+		pb.PointServer_SetPoints_FullMethodName,
+	} {
+		if slices.Contains(policy.Methods, method) {
+			t.Errorf("mutation %s must not be retried", method)
+		}
+	}
+}
+
+func Test_container_authSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		envVars map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "Disabled by default", envVars: map[string]string{}, wantNil: true},
+		{name: "Disabled", envVars: map[string]string{AuthEnabled: "false", AuthHMACSecret: "secret"}, wantNil: true},
+		{name: "HMAC secret", envVars: map[string]string{AuthEnabled: "true", AuthHMACSecret: "secret"}},
+		{name: "Enabled without keys", envVars: map[string]string{AuthEnabled: "true"}, wantErr: true},
+		{name: "Missing JWKS file", envVars: map[string]string{AuthEnabled: "true", AuthJWKSFile: "missing.json"}, wantErr: true},
+		{name: "Invalid flag", envVars: map[string]string{AuthEnabled: "yes please"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &container{environmentVariables: tt.envVars}
+			got, err := c.authSetup()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("authSetup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("authSetup() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_container_cursorSetup(t *testing.T) {
+	for _, secret := range []string{"secret", ""} {
+		c := &container{logger: zap.NewNop(), environmentVariables: map[string]string{CursorSecret: secret}}
+		got, err := c.cursorSetup()
+		if err != nil || got == nil {
+			t.Errorf("cursorSetup() with secret %q = %v, %v", secret, got, err)
+		}
+	}
+}
+
+func Test_container_apiKeysSetup(t *testing.T) {
+	c := &container{environmentVariables: map[string]string{APIKeys: " key-1, ,key-2,"}}
+	if got := c.apiKeysSetup(); !reflect.DeepEqual(got, []string{"key-1", "key-2"}) {
+		t.Errorf("apiKeysSetup() = %v, want [key-1 key-2]", got)
+	}
+	c = &container{environmentVariables: map[string]string{}}
+	if got := c.apiKeysSetup(); got != nil {
+		t.Errorf("apiKeysSetup() without API_KEYS = %v, want none", got)
+	}
+}
+
+func Test_container_tracingSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		envVars map[string]string
+		wantErr bool
+	}{
+		{name: "Disabled by default", envVars: map[string]string{}},
+		{name: "Disabled", envVars: map[string]string{TracingExporter: "none"}},
+		{name: "File", envVars: map[string]string{TracingExporter: "FILE", TracingFile: filepath.Join(t.TempDir(), "traces.json"), TracingSampleRatio: "0.5"}},
+		{name: "Unknown exporter", envVars: map[string]string{TracingExporter: "zipkin"}, wantErr: true},
+		{name: "Invalid sample ratio", envVars: map[string]string{TracingExporter: "stdout", TracingSampleRatio: "all"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer otel.SetTracerProvider(noop.NewTracerProvider())
+			c := &container{environmentVariables: tt.envVars}
+			shutdown, err := c.tracingSetup()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tracingSetup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_InitScriptWithoutMigrateOnStart(t *testing.T) {
+	_, err := New(map[string]string{
+		DBMaxIdle:      "10",
+		DBMaxOpen:      "100",
+		DBMaxLifeTime:  "1",
+		DBMaxIdleTime:  "10",
+		DatabaseURL:    "file:containerinitscripttest?mode=memory",
+		DB:             "SQLITE",
+		DBInitScript:   "../../database/sqlite/data_script.sql",
+		MigrateOnStart: "false",
+		GormConf:       "../../config/gorm-logger-config.json",
+		ZapConf:        "../../config/zap-logger-config.json",
+		Port:           "8080",
+		PprofEnable:    "false",
+		PointSrvcAddr:  "point_service:8185",
+		PointSrvcMax:   "10",
+	})
+	if err == nil {
+		t.Fatal("New() error = nil, want DB_INIT_SCRIPT without MIGRATE_ON_START error")
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/glebarez/sqlite"
+	"github.com/syedomair/backend-microservices/lib/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -35,6 +38,9 @@ func NewDBConnectionAdapter(dbName, url string, dbMaxIdle, dbMaxOpen, dbMaxLifeT
 			dbMaxLifeTime: dbMaxLifeTime,
 			dbMaxIdleTime: dbMaxIdleTime,
 			gormConf:      gormConf}
+	case Sqlite:
+		return &SQLiteAdapter{dbUrl: url,
+			gormConf: gormConf}
 	}
 	return &PostgresAdapter{dbUrl: url,
 		dbMaxIdle:     dbMaxIdle,
@@ -124,6 +130,54 @@ func (m *MySQLAdapter) MakeConnection() (*gorm.DB, error) {
 	return db, nil
 }
 
+// SQLiteAdapter connects to a SQLite file or in-memory database, e.g.
+// "file:dev.db" or "file::memory:". It is meant for local development and tests.
+type SQLiteAdapter struct {
+	dbUrl    string
+	gormConf string
+	db       *gorm.DB
+	mu       sync.Mutex
+}
+
+var _ Db = (*SQLiteAdapter)(nil)
+
+func NewSQLiteAdapter(url string, gormConf string) *SQLiteAdapter {
+	return &SQLiteAdapter{dbUrl: url,
+		gormConf: gormConf,
+	}
+}
+
+func (s *SQLiteAdapter) MakeConnection() (*gorm.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	// A single connection that is never recycled: every new connection to an
+	// in-memory database would see an empty database, and SQLite only allows one writer anyway.
+	db, err := makeConnection(sqlite.Open(sqliteDSN(s.dbUrl)), s.dbUrl, 1, 1, 0, 0, s.gormConf)
+	if err != nil {
+		return nil, err
+	}
+
+	s.db = db
+	return db, nil
+}
+
+// sqliteDSN turns on foreign key enforcement, which SQLite leaves off by default.
+func sqliteDSN(url string) string {
+	if url == "" || strings.Contains(url, "foreign_keys") {
+		return url
+	}
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	return url + separator + "_pragma=foreign_keys(1)"
+}
+
 func makeConnection(dialector gorm.Dialector, url string, dbMaxIdle, dbMaxOpen, dbMaxLifeTime, dbMaxIdleTime int, gormConf string) (*gorm.DB, error) {
 	if url == "" {
 		return nil, errors.New("database URL is required")
@@ -159,10 +213,13 @@ func makeConnection(dialector gorm.Dialector, url string, dbMaxIdle, dbMaxOpen,
 		},
 	)
 
-	gormDB, err := gorm.Open(dialector, &gorm.Config{Logger: newLogger})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{Logger: newLogger, TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
+	if err := gormDB.Use(&tracing.Plugin{}); err != nil {
+		return nil, err
+	}
 
 	sqlDB, err := gormDB.DB()
 	if err != nil {
@@ -103,6 +103,48 @@ func TestMySQLAdapter_MakeConnection(t *testing.T) {
 	}
 }
 
+// TestSQLiteAdapter_MakeConnection tests the MakeConnection method of SQLiteAdapter
+func TestSQLiteAdapter_MakeConnection(t *testing.T) {
+	adapter := NewSQLiteAdapter("file:adaptertest?mode=memory", "../../config/gorm-logger-config.json")
+
+	db, err := adapter.MakeConnection()
+	if err != nil {
+		t.Fatalf("SQLiteAdapter.MakeConnection() error = %v", err)
+	}
+	again, err := adapter.MakeConnection()
+	if err != nil || again != db {
+		t.Errorf("SQLiteAdapter.MakeConnection() did not reuse the connection")
+	}
+
+	var foreignKeys int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error; err != nil {
+		t.Fatalf("PRAGMA foreign_keys error = %v", err)
+	}
+	if foreignKeys != 1 {
+		t.Errorf("foreign_keys = %d, want 1", foreignKeys)
+	}
+
+	if _, err := NewSQLiteAdapter("", "../../config/gorm-logger-config.json").MakeConnection(); err == nil {
+		t.Errorf("SQLiteAdapter.MakeConnection() with empty URL, want error")
+	}
+}
+
+func Test_sqliteDSN(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "file:dev.db", want: "file:dev.db?_pragma=foreign_keys(1)"},
+		{url: "file::memory:?cache=shared", want: "file::memory:?cache=shared&_pragma=foreign_keys(1)"},
+		{url: "file:dev.db?_pragma=foreign_keys(0)", want: "file:dev.db?_pragma=foreign_keys(0)"},
+	}
+	for _, tt := range tests {
+		if got := sqliteDSN(tt.url); got != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
 // TestNewDBConnectionAdapter tests the NewDBConnectionAdapter function
 func TestNewDBConnectionAdapter(t *testing.T) {
 	tests := []struct {
@@ -122,6 +164,11 @@ func TestNewDBConnectionAdapter(t *testing.T) {
 				expected: &MySQLAdapter{},
 			},
 		*/
+		{
+			name:     "SQLite adapter",
+			dbName:   Sqlite,
+			expected: &SQLiteAdapter{},
+		},
 		{
 			name:     "Default adapter (Postgres)",
 			dbName:   "unknown",
@@ -141,6 +188,10 @@ func TestNewDBConnectionAdapter(t *testing.T) {
 				if tt.dbName != Mysql {
 					t.Errorf("NewDBConnectionAdapter() returned unexpected adapter type for dbName %v", tt.dbName)
 				}
+			case *SQLiteAdapter:
+				if tt.dbName != Sqlite {
+					t.Errorf("NewDBConnectionAdapter() returned unexpected adapter type for dbName %v", tt.dbName)
+				}
 			default:
 				t.Errorf("NewDBConnectionAdapter() returned unexpected adapter type")
 			}
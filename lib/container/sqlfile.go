@@ -0,0 +1,27 @@
+package container
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/syedomair/backend-microservices/lib/migrate"
+	"gorm.io/gorm"
+)
+
+// RunSQLFile executes every statement of the SQL script at path, in order, inside one transaction.
+// It is used to seed a database once its schema has been migrated.
+func RunSQLFile(db *gorm.DB, path string) error {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read sql file: %v", err)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range migrate.SplitStatements(string(script)) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to run %s: %v", path, err)
+			}
+		}
+		return nil
+	})
+}
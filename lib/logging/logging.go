@@ -0,0 +1,103 @@
+// Package logging carries the request ID and a logger scoped to it through contexts, and forwards
+// the request ID to gRPC servers in the call metadata, so the logs of every service that handled a
+// request can be found by its ID.
+package logging
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// RequestIDHeader is the HTTP header a request ID is read from and echoed in.
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey is the gRPC metadata key the request ID is forwarded in.
+	RequestIDMetadataKey = "x-request-id"
+
+	maxRequestIDLength = 128
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "requestID"
+	loggerKey    contextKey = "logger"
+)
+
+// NewRequestID returns a new random request ID.
+func NewRequestID() string {
+	return uuid.New().String()
+}
+
+// ValidRequestID reports whether a request ID sent by a client may be reused: up to 128 letters,
+// digits and "-_.:", so it cannot forge log lines or headers.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns ctx carrying the request ID and logger with fields request_id and, when ctx
+// holds a valid span, trace_id.
+func NewContext(ctx context.Context, logger *zap.Logger, requestID string) context.Context {
+	logger = logger.With(zap.String("request_id", requestID))
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With(zap.String("trace_id", spanContext.TraceID().String()))
+	}
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// RequestID returns the request ID in ctx, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// FromContext returns the request-scoped logger in ctx, or fallback outside a request.
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// UnaryClientInterceptor forwards the request ID in ctx to the server.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if requestID := RequestID(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, requestID)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerInterceptor scopes a logger derived from logger to the request ID forwarded by the
+// client, or to a new one for clients that did not send one.
+func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDMetadataKey); len(values) > 0 && ValidRequestID(values[0]) {
+				requestID = values[0]
+			}
+		}
+		if requestID == "" {
+			requestID = NewRequestID()
+		}
+		return handler(NewContext(ctx, logger, requestID), req)
+	}
+}
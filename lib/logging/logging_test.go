@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "5f0c8c8e-2a4b-4d3e-9f5a-0b1c2d3e4f5a", want: true},
+		{id: "edge:req_01.a", want: true},
+		{id: "", want: false},
+		{id: "has space", want: false},
+		{id: "line\nbreak", want: false},
+		{id: strings.Repeat("a", 129), want: false},
+	}
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	fallback := zap.NewNop()
+
+	if got := FromContext(context.Background(), fallback); got != fallback {
+		t.Errorf("FromContext() outside a request = %v, want the fallback", got)
+	}
+
+	ctx := NewContext(context.Background(), zap.New(core), "req-1")
+	if got := RequestID(ctx); got != "req-1" {
+		t.Errorf("RequestID() = %q, want req-1", got)
+	}
+	FromContext(ctx, fallback).Info("hello")
+	if entries := logs.FilterField(zap.String("request_id", "req-1")).All(); len(entries) != 1 {
+		t.Errorf("logged %v, want one entry with the request ID", logs.All())
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	server := UnaryServerInterceptor(zap.New(core))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		FromContext(ctx, zap.NewNop()).Info("handled")
+		return RequestID(ctx), nil
+	}
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	ctx := NewContext(context.Background(), zap.NewNop(), "req-2")
+	if err := UnaryClientInterceptor()(ctx, "/point.PointServer/GetUserPoints", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if got := outgoing.Get(RequestIDMetadataKey); len(got) != 1 || got[0] != "req-2" {
+		t.Fatalf("outgoing metadata = %v, want the request ID", outgoing)
+	}
+
+	got, err := server(metadata.NewIncomingContext(context.Background(), outgoing), nil, &grpc.UnaryServerInfo{}, handler)
+	if err != nil || got != "req-2" {
+		t.Errorf("server request ID = %v, %v, want req-2", got, err)
+	}
+	if entries := logs.FilterField(zap.String("request_id", "req-2")).All(); len(entries) != 1 {
+		t.Errorf("logged %v, want the server log to carry the request ID", logs.All())
+	}
+
+	invalid := metadata.Pairs(RequestIDMetadataKey, "bad id")
+	got, err = server(metadata.NewIncomingContext(context.Background(), invalid), nil, &grpc.UnaryServerInfo{}, handler)
+	if err != nil || got == "" || got == "bad id" {
+		t.Errorf("server request ID for an invalid one = %v, %v, want a new ID", got, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package metrics registers Prometheus collectors so that creating the same metric twice, such as
+// from several routers, tests or packages, reuses the first one instead of failing.
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Register registers collector, or returns the equivalent collector registered before. Other
+// failures are returned with collector, which then counts but is not exported.
+func Register[T prometheus.Collector](registerer prometheus.Registerer, collector T) (T, error) {
+	err := registerer.Register(collector)
+	if err == nil {
+		return collector, nil
+	}
+	already := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &already) {
+		if existing, ok := already.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+	return collector, err
+}
+
+// MustRegister is Register with the default registerer for package level metrics. It panics when
+// collector conflicts with a different metric of the same name.
+func MustRegister[T prometheus.Collector](collector T) T {
+	collector, err := Register(prometheus.DefaultRegisterer, collector)
+	if err != nil {
+		panic(err)
+	}
+	return collector
+}
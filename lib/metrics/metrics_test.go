@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegister(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	newCounter := func() *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "calls_total", Help: "Calls"}, []string{"name"})
+	}
+
+	first, err := Register(registry, newCounter())
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	second, err := Register(registry, newCounter())
+	if err != nil || second != first {
+		t.Errorf("Register() of an equivalent collector = %p, %v, want the first one %p", second, err, first)
+	}
+
+	conflicting := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "calls_total", Help: "Calls"}, []string{"method"})
+	if got, err := Register(registry, conflicting); err == nil || got != conflicting {
+		t.Errorf("Register() of a conflicting collector = %p, %v, want it back with an error", got, err)
+	}
+}
@@ -0,0 +1,218 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var counter atomic.Int64
+
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:migrate%d?mode=memory&_pragma=foreign_keys(1)", counter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db := newSQLiteDB(t)
+	m, err := New(db, zap.NewNop())
+	require.NoError(t, err)
+	latest := m.Migrations()[len(m.Migrations())-1].Version
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+
+	applied, err := m.Up()
+	require.NoError(t, err)
+	assert.Equal(t, len(m.Migrations()), applied)
+	assert.True(t, db.Migrator().HasTable("point_transactions"))
+
+	version, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	// A second run has nothing left to do.
+	applied, err = m.Up()
+	require.NoError(t, err)
+	assert.Equal(t, 0, applied)
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, status.Name)
+		assert.NotNil(t, status.AppliedAt, status.Name)
+	}
+
+	// Reverts 0003_search and 0002_point_transactions.
+	reverted, err := m.Down(2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reverted)
+	assert.False(t, db.Migrator().HasTable("point_transactions"))
+	assert.True(t, db.Migrator().HasTable("points"))
+
+	statuses, err = m.Status()
+	require.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+	assert.True(t, statuses[0].Applied)
+
+	reverted, err = m.Down(100)
+	require.NoError(t, err)
+	assert.Equal(t, len(m.Migrations())-2, reverted)
+	assert.False(t, db.Migrator().HasTable("user"))
+
+	version, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+
+	_, err = m.Down(0)
+	assert.ErrorIs(t, err, ErrInvalidSteps)
+}
+
+func TestMigrator_LedgerSurvivesUserDeletion(t *testing.T) {
+	db := newSQLiteDB(t)
+	m, err := New(db, zap.NewNop())
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`INSERT INTO "user" (id, name, email) VALUES ('u1', 'Alice', 'alice@example.com')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO point_transactions (user_id, delta, balance_after, reason, actor) VALUES ('u1', 5, 5, 'signup', 'system')`).Error)
+	require.NoError(t, db.Exec(`DELETE FROM "user" WHERE id = 'u1'`).Error)
+
+	var rows []struct {
+		UserID *string
+		Delta  int
+	}
+	require.NoError(t, db.Raw("SELECT user_id, delta FROM point_transactions").Scan(&rows).Error)
+	require.Len(t, rows, 1)
+	assert.Nil(t, rows[0].UserID)
+	assert.Equal(t, 5, rows[0].Delta)
+}
+
+func TestMigrator_UpRejectsUnknownVersion(t *testing.T) {
+	db := newSQLiteDB(t)
+	m, err := New(db, zap.NewNop())
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&schemaMigration{Version: 9999, Name: "from_the_future"}).Error)
+
+	_, err = m.Up()
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	db := newSQLiteDB(t)
+	m := &Migrator{db: db, logger: zap.NewNop(), dialect: "sqlite", migrations: []Migration{
+		{Version: 1, Name: "ok", Up: "CREATE TABLE a (id INT);", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "broken", Up: "CREATE TABLE b (id INT); INSERT INTO missing VALUES (1);", Down: "DROP TABLE b;"},
+	}}
+
+	applied, err := m.Up()
+	assert.Error(t, err)
+	assert.Equal(t, 1, applied)
+	assert.True(t, db.Migrator().HasTable("a"))
+	assert.False(t, db.Migrator().HasTable("b"))
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+}
+
+func TestNew_UnsupportedDialect(t *testing.T) {
+	_, err := loadMigrations(migrationsFS, "migrations/sqlserver")
+	assert.True(t, errors.Is(err, ErrUnsupportedDialect))
+}
+
+func Test_loadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int64
+		wantErr bool
+	}{
+		{
+			name: "Sorted by version",
+			files: fstest.MapFS{
+				"m/0010_b.up.sql":   {Data: []byte("b")},
+				"m/0010_b.down.sql": {Data: []byte("b")},
+				"m/0002_a.up.sql":   {Data: []byte("a")},
+				"m/0002_a.down.sql": {Data: []byte("a")},
+			},
+			want: []int64{2, 10},
+		},
+		{
+			name: "Missing down script",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql": {Data: []byte("a")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Conflicting names",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql":   {Data: []byte("a")},
+				"m/0001_b.down.sql": {Data: []byte("b")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unexpected file",
+			files: fstest.MapFS{
+				"m/README.md": {Data: []byte("x")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var got []int64
+			for _, migration := range migrations {
+				got = append(got, migration.Version)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEmbeddedMigrationsMatchAcrossDialects(t *testing.T) {
+	var reference []Migration
+	for _, dialect := range []string{"postgres", "mysql", "sqlite"} {
+		migrations, err := loadMigrations(migrationsFS, "migrations/"+dialect)
+		require.NoError(t, err, dialect)
+		if reference == nil {
+			reference = migrations
+			continue
+		}
+		require.Len(t, migrations, len(reference), dialect)
+		for i := range migrations {
+			assert.Equal(t, reference[i].Version, migrations[i].Version, dialect)
+			assert.Equal(t, reference[i].Name, migrations[i].Name, dialect)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS department (
+    id CHAR(36) NOT NULL DEFAULT (UUID()) PRIMARY KEY,  -- Generate a new UUID by default
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS `user` (
+    id CHAR(36) NOT NULL DEFAULT (UUID()) PRIMARY KEY,  -- Generate a new UUID by default
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    department_id CHAR(36),
+    age INT CHECK (age >= 0),  -- Ensures age is non-negative
+    salary DOUBLE CHECK (salary >= 0),  -- Ensures salary is non-negative
+    FOREIGN KEY (department_id) REFERENCES department(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS points (
+    id CHAR(36) NOT NULL DEFAULT (UUID()) PRIMARY KEY,  -- Generate a new UUID by default
+    user_id CHAR(36),
+    points INT,
+    FOREIGN KEY (user_id) REFERENCES `user`(id) ON DELETE SET NULL
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS point_transactions (
+    id CHAR(36) NOT NULL DEFAULT (UUID()) PRIMARY KEY,  -- Generate a new UUID by default
+    user_id CHAR(36),  -- Kept, unattributed, when the user is deleted
+    delta INT NOT NULL,  -- Signed change applied to the balance
+    balance_after INT NOT NULL CHECK (balance_after >= 0),  -- Balance once the change was applied
+    reason VARCHAR(255) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES `user`(id) ON DELETE SET NULL,
+    INDEX point_transactions_user_id_created_at_idx (user_id, created_at)
+);
+
+-- Opening balances, so the ledger explains every balance that existed before it did.
+INSERT INTO point_transactions (user_id, delta, balance_after, reason, actor)
+SELECT p.user_id, COALESCE(p.points, 0), COALESCE(p.points, 0), 'opening_balance', 'system'
+FROM points p
+WHERE p.user_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM point_transactions t WHERE t.user_id = p.user_id);
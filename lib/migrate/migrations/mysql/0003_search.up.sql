@@ -0,0 +1,2 @@
+-- Search matches substrings with LIKE on this dialect, which no index can serve; the full-text and
+-- trigram indexes of this version only exist on postgres.
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.points;
+DROP TABLE IF EXISTS public.user;
+DROP TABLE IF EXISTS department;
@@ -1,12 +1,5 @@
-
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";  -- Enable UUID extension if not already enabled
 
-DROP TABLE public.user CASCADE;
-DROP TABLE department CASCADE;
-DROP TABLE points CASCADE;
-
-
-
 CREATE TABLE IF NOT EXISTS department (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),  -- Generate a new UUID by default
     name VARCHAR(255) NOT NULL,
@@ -22,8 +15,8 @@ CREATE TABLE IF NOT EXISTS public.user (
     salary DOUBLE PRECISION CHECK (salary >= 0)  -- Ensures salary is non-negative
 );
 
-CREATE TABLE IF NOT EXISTS points (
+CREATE TABLE IF NOT EXISTS public.points (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),  -- Generate a new UUID by default
     user_id UUID REFERENCES public.user(id) ON DELETE SET NULL,  -- Foreign key constraint
-    points INT 
-);
\ No newline at end of file
+    points INT
+);
@@ -0,0 +1,7 @@
+-- pg_trgm is left installed, since other objects may depend on it.
+DROP INDEX IF EXISTS department_search_idx;
+DROP INDEX IF EXISTS user_search_idx;
+DROP INDEX IF EXISTS department_address_trgm_idx;
+DROP INDEX IF EXISTS department_name_trgm_idx;
+DROP INDEX IF EXISTS user_email_trgm_idx;
+DROP INDEX IF EXISTS user_name_trgm_idx;
@@ -0,0 +1,23 @@
+-- pg_trgm makes fuzzy search fast, but managed databases may not allow it; search still works without it.
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'pg_trgm is not available, search will not use trigram indexes: %', SQLERRM;
+END
+$$;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+        CREATE INDEX IF NOT EXISTS user_name_trgm_idx ON public.user USING gin (name gin_trgm_ops);
+        CREATE INDEX IF NOT EXISTS user_email_trgm_idx ON public.user USING gin (email gin_trgm_ops);
+        CREATE INDEX IF NOT EXISTS department_name_trgm_idx ON department USING gin (name gin_trgm_ops);
+        CREATE INDEX IF NOT EXISTS department_address_trgm_idx ON department USING gin (address gin_trgm_ops);
+    END IF;
+END
+$$;
+
+-- The expressions must match the ones lib/search builds, or the planner will not use the indexes.
+CREATE INDEX IF NOT EXISTS user_search_idx ON public.user USING gin (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(email, '')));
+CREATE INDEX IF NOT EXISTS department_search_idx ON department USING gin (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(address, '')));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS points;
+DROP TABLE IF EXISTS "user";
+DROP TABLE IF EXISTS department;
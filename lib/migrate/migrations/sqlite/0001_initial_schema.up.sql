@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS department (
+    id TEXT PRIMARY KEY NOT NULL DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),  -- Generate a new UUID by default
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS "user" (
+    id TEXT PRIMARY KEY NOT NULL DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),  -- Generate a new UUID by default
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    department_id TEXT REFERENCES department(id) ON DELETE SET NULL,  -- Foreign key constraint
+    age INT CHECK (age >= 0),  -- Ensures age is non-negative
+    salary DOUBLE PRECISION CHECK (salary >= 0)  -- Ensures salary is non-negative
+);
+
+CREATE TABLE IF NOT EXISTS points (
+    id TEXT PRIMARY KEY NOT NULL DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),  -- Generate a new UUID by default
+    user_id TEXT REFERENCES "user"(id) ON DELETE SET NULL,  -- Foreign key constraint
+    points INT
+);
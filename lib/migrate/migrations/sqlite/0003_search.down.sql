@@ -0,0 +1 @@
+-- Nothing to revert; see 0003_search.up.sql.
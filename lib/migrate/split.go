@@ -0,0 +1,81 @@
+package migrate
+
+import "strings"
+
+// SplitStatements splits a script on semicolons that are not inside quotes, Postgres dollar quotes or comments.
+// Comments are dropped. Bodies with their own semicolons, such as DO blocks, must be dollar quoted.
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := i + 1
+			for end < len(script) && script[end] != ch {
+				end++
+			}
+			if end == len(script) {
+				end--
+			}
+			current.WriteString(script[i : end+1])
+			i = end
+		case ch == '$':
+			tag, ok := dollarTag(script[i:])
+			if !ok {
+				current.WriteByte(ch)
+				break
+			}
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				end = len(script)
+			} else {
+				end = i + len(tag) + end + len(tag)
+			}
+			current.WriteString(script[i:end])
+			i = end - 1
+		case ch == '-' && i+1 < len(script) && script[i+1] == '-':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case ch == '/' && i+1 < len(script) && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case ch == ';':
+			flush()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	flush()
+	return statements
+}
+
+// dollarTag returns the $tag$ that s starts with, such as $$ or $body$.
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '$':
+			return s[:i+1], true
+		case ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 1 && ch >= '0' && ch <= '9':
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "Multiple statements",
+			script: "CREATE TABLE a (id INT);\nINSERT INTO a VALUES (1);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "INSERT INTO a VALUES (1)"},
+		},
+		{
+			name:   "Semicolons inside quotes",
+			script: "INSERT INTO a (name) VALUES ('x;y');INSERT INTO \"b;c\" VALUES (2)",
+			want:   []string{"INSERT INTO a (name) VALUES ('x;y')", "INSERT INTO \"b;c\" VALUES (2)"},
+		},
+		{
+			name:   "Comments are dropped",
+			script: "-- header; comment\nSELECT 1; /* block; comment */ SELECT 2; -- trailing",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "Dollar quoted bodies",
+			script: "DO $$\nBEGIN\n    CREATE EXTENSION x; -- may fail\nEND\n$$;\nSELECT $tag$a;$$b$tag$; SELECT $1",
+			want:   []string{"DO $$\nBEGIN\n    CREATE EXTENSION x; -- may fail\nEND\n$$", "SELECT $tag$a;$$b$tag$", "SELECT $1"},
+		},
+		{
+			name:   "Empty script",
+			script: "  \n-- nothing here\n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -27,9 +27,30 @@ func (m *MockPointServiceClient) GetUserListPoints(ctx context.Context, in *pb.U
 	return &pb.UserListPointResponse{UserPoints: mapUserPoints}, nil
 }
 
+func (m *MockPointServiceClient) AwardPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error) {
+	return &pb.PointBalanceReply{UserId: in.GetUserId(), Balance: 10 + in.GetPoints()}, nil
+}
+func (m *MockPointServiceClient) DeductPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error) {
+	return &pb.PointBalanceReply{UserId: in.GetUserId(), Balance: 10 - in.GetPoints()}, nil
+}
+func (m *MockPointServiceClient) SetPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error) {
+	return &pb.PointBalanceReply{UserId: in.GetUserId(), Balance: in.GetPoints()}, nil
+}
+func (m *MockPointServiceClient) GetLeaderboard(ctx context.Context, in *pb.LeaderboardRequest) (*pb.LeaderboardReply, error) {
+	return &pb.LeaderboardReply{Entries: []*pb.LeaderboardEntry{
+		{Rank: 1, UserId: "1", Points: 20},
+		{Rank: 2, UserId: "2", Points: 10},
+		{Rank: 2, UserId: "3", Points: 10},
+	}}, nil
+}
+func (m *MockPointServiceClient) GetUserPointHistory(ctx context.Context, in *pb.PointHistoryRequest) (*pb.PointHistoryReply, error) {
+	return &pb.PointHistoryReply{Limit: in.GetLimit(), Offset: in.GetOffset()}, nil
+}
+
 type MockConnectionPool struct {
-	GetFunc func() (*grpc.ClientConn, error)
-	PutFunc func(conn *grpc.ClientConn)
+	GetFunc        func() (*grpc.ClientConn, error)
+	GetContextFunc func(ctx context.Context) (*grpc.ClientConn, error)
+	PutFunc        func(conn *grpc.ClientConn)
 }
 
 func (m *MockConnectionPool) Get() (*grpc.ClientConn, error) {
@@ -39,6 +60,14 @@ func (m *MockConnectionPool) Get() (*grpc.ClientConn, error) {
 	return nil, errors.New("GetFunc not implemented")
 }
 
+// GetContext falls back to GetFunc when GetContextFunc is not set.
+func (m *MockConnectionPool) GetContext(ctx context.Context) (*grpc.ClientConn, error) {
+	if m.GetContextFunc != nil {
+		return m.GetContextFunc(ctx)
+	}
+	return m.Get()
+}
+
 func (m *MockConnectionPool) Put(conn *grpc.ClientConn) {
 	if m.PutFunc != nil {
 		m.PutFunc(conn)
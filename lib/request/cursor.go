@@ -0,0 +1,196 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by the CursorCodec or no longer apply.
+var ErrInvalidCursor = errors.New("invalid 'cursor' value in query string. ")
+
+// CursorCodec signs and verifies the opaque cursors of keyset pagination, so clients cannot forge
+// a position or an ordering.
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec returns a CursorCodec that signs cursors with key.
+func NewCursorCodec(key []byte) *CursorCodec {
+	return &CursorCodec{key: key}
+}
+
+// cursor is the position after (or, when Backward, before) a row in a given ordering.
+type cursor struct {
+	OrderBy  string        `json:"o"`
+	Sort     string        `json:"s"`
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+func (c *CursorCodec) encode(cur cursor) (string, error) {
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+func (c *CursorCodec) decode(value string) (cursor, error) {
+	cur := cursor{}
+	encodedPayload, encodedSignature, ok := strings.Cut(value, ".")
+	if !ok {
+		return cur, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return cur, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cur); err != nil {
+		return cur, ErrInvalidCursor
+	}
+	for i, value := range cur.Values {
+		if cur.Values[i], err = sqlValue(value); err != nil {
+			return cur, ErrInvalidCursor
+		}
+	}
+	return cur, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Keyset restricts a cursor page to the rows after the cursor in the ORDER BY of QueryParams.
+type Keyset struct {
+	Where string
+	Args  []interface{}
+	// Backward pages run in reverse order; Paginate puts the rows back in order.
+	Backward bool
+}
+
+// newKeyset builds "(a > ?) OR (a = ? AND b < ?) ..." for the ordering fields, with the
+// comparisons flipped when paging backward.
+func newKeyset(fields []sortField, values []interface{}, backward bool) *Keyset {
+	disjuncts := make([]string, 0, len(fields))
+	args := []interface{}{}
+	for i, field := range fields {
+		conjuncts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, fields[j].expression+" = ?")
+			args = append(args, values[j])
+		}
+		operator := ">"
+		if field.desc != backward {
+			operator = "<"
+		}
+		conjuncts = append(conjuncts, field.expression+" "+operator+" ?")
+		args = append(args, values[i])
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+	return &Keyset{Where: "(" + strings.Join(disjuncts, " OR ") + ")", Args: args, Backward: backward}
+}
+
+// Cursors are the cursors of the pages around the current one; empty when there is no such page.
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+// Paginate trims rows fetched with QueryParams.FetchLimit to the page, restores their order after a
+// backward cursor, and returns the cursors of the neighbouring pages. count, the total number of
+// rows, tells whether there is a next page in offset mode. Rows must marshal to JSON objects with
+// a key for every ordering column.
+func Paginate[T any](q QueryParams, rows []T, count int) ([]T, Cursors, error) {
+	cursors := Cursors{}
+	hasMore := q.Page+len(rows) < count
+	if q.Keyset != nil {
+		hasMore = len(rows) > q.Limit
+		if hasMore {
+			rows = rows[:q.Limit]
+		}
+		if q.Keyset.Backward {
+			slices.Reverse(rows)
+		}
+	}
+	if q.cursors == nil || len(rows) == 0 {
+		return rows, cursors, nil
+	}
+
+	hasNext, hasPrev := hasMore, q.Page > 0
+	if q.Keyset != nil {
+		// The page was reached from a neighbour, so that side always exists.
+		hasNext, hasPrev = hasMore, true
+		if q.Keyset.Backward {
+			hasNext, hasPrev = true, hasMore
+		}
+	}
+
+	var err error
+	if hasNext {
+		if cursors.Next, err = q.cursorAt(rows[len(rows)-1], false); err != nil {
+			return rows, Cursors{}, err
+		}
+	}
+	if hasPrev {
+		if cursors.Prev, err = q.cursorAt(rows[0], true); err != nil {
+			return rows, Cursors{}, err
+		}
+	}
+	return rows, cursors, nil
+}
+
+// cursorAt returns the cursor after row, or before it when backward.
+func (q QueryParams) cursorAt(row interface{}, backward bool) (string, error) {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return "", err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	columns := map[string]interface{}{}
+	if err := decoder.Decode(&columns); err != nil {
+		return "", err
+	}
+
+	values := make([]interface{}, len(q.fields))
+	for i, field := range q.fields {
+		value, ok := columns[field.column]
+		if !ok || value == nil {
+			return "", fmt.Errorf("cannot build a cursor: column %q is missing or null", field.column)
+		}
+		if values[i], err = sqlValue(value); err != nil {
+			return "", err
+		}
+	}
+	return q.cursors.encode(cursor{OrderBy: q.orderby, Sort: q.Sort, Values: values, Backward: backward})
+}
+
+// sqlValue turns JSON numbers into int64 or float64 so they bind as numeric parameters.
+func sqlValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported cursor value %T", value)
+}
@@ -0,0 +1,153 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type row struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+var sortable = append(NotNull("id", "name"), SortColumn{Name: "age", NullAs: "0"})
+
+func validate(t *testing.T, cursors *CursorCodec, query url.Values) QueryParams {
+	t.Helper()
+	req, err := http.NewRequest("GET", "/?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := ValidateQueryString(req, "2", "0", "name", "asc", sortable, cursors)
+	if err != nil {
+		t.Fatalf("ValidateQueryString() error = %v", err)
+	}
+	return params
+}
+
+func TestValidateQueryString_Cursor(t *testing.T) {
+	cursors := NewCursorCodec([]byte("secret"))
+	first := validate(t, cursors, url.Values{"orderby": {"-age"}})
+	_, page, err := Paginate(first, []row{{ID: "a", Age: 40}, {ID: "b", Age: 30}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Next == "" || page.Prev != "" {
+		t.Fatalf("first page cursors = %+v, want only next", page)
+	}
+
+	next := validate(t, cursors, url.Values{"cursor": {page.Next}, "orderby": {"name"}, "page": {"3"}})
+	if next.OrderBy != "COALESCE(age, 0) DESC, id ASC" || next.Page != 0 || next.FetchLimit() != 3 {
+		t.Errorf("next params = %+v", next)
+	}
+	wantKeyset := &Keyset{Where: "((COALESCE(age, 0) < ?) OR (COALESCE(age, 0) = ? AND id > ?))", Args: []interface{}{int64(30), int64(30), "b"}}
+	if !reflect.DeepEqual(next.Keyset, wantKeyset) {
+		t.Errorf("next keyset = %+v, want %+v", next.Keyset, wantKeyset)
+	}
+
+	rows, page, err := Paginate(next, []row{{ID: "c", Age: 30}, {ID: "d", Age: 25}, {ID: "e", Age: 20}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || page.Next == "" || page.Prev == "" {
+		t.Fatalf("second page rows = %+v, cursors = %+v", rows, page)
+	}
+
+	prev := validate(t, cursors, url.Values{"cursor": {page.Prev}})
+	if prev.OrderBy != "COALESCE(age, 0) ASC, id DESC" || !prev.Keyset.Backward {
+		t.Errorf("prev params = %+v", prev)
+	}
+	rows, page, err = Paginate(prev, []row{{ID: "b", Age: 30}, {ID: "a", Age: 40}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].ID != "a" || rows[1].ID != "b" {
+		t.Errorf("backward page rows = %+v, want them back in order", rows)
+	}
+	if page.Next == "" || page.Prev != "" {
+		t.Errorf("backward page cursors = %+v, want only next", page)
+	}
+}
+
+func TestValidateQueryString_InvalidCursor(t *testing.T) {
+	cursors := NewCursorCodec([]byte("secret"))
+	params := validate(t, cursors, url.Values{"orderby": {"age"}})
+	_, page, err := Paginate(params, []row{{ID: "a", Age: 40}, {ID: "b", Age: 30}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(page.Next, ".")
+
+	forged, err := NewCursorCodec([]byte("other")).encode(cursor{OrderBy: "age", Sort: "asc", Values: []interface{}{1, "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notSortable, err := cursors.encode(cursor{OrderBy: "salary", Sort: "asc", Values: []interface{}{1, "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongValues, err := cursors.encode(cursor{OrderBy: "age", Sort: "asc", Values: []interface{}{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cursors *CursorCodec
+		cursor  string
+	}{
+		{name: "Not supported", cursors: nil, cursor: page.Next},
+		{name: "Garbage", cursors: cursors, cursor: "garbage"},
+		{name: "Tampered payload", cursors: cursors, cursor: payload + "x." + signature},
+		{name: "Other key", cursors: cursors, cursor: forged},
+		{name: "Column no longer sortable", cursors: cursors, cursor: notSortable},
+		{name: "Value count mismatch", cursors: cursors, cursor: wrongValues},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+url.Values{"cursor": {tt.cursor}}.Encode(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ValidateQueryString(req, "2", "0", "name", "asc", sortable, tt.cursors); err == nil {
+				t.Errorf("ValidateQueryString() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestPaginate_OffsetMode(t *testing.T) {
+	cursors := NewCursorCodec([]byte("secret"))
+	tests := []struct {
+		name     string
+		query    url.Values
+		rows     []row
+		count    int
+		wantNext bool
+		wantPrev bool
+	}{
+		{name: "First page", query: url.Values{}, rows: []row{{ID: "a"}, {ID: "b"}}, count: 5, wantNext: true},
+		{name: "Middle page", query: url.Values{"page": {"2"}}, rows: []row{{ID: "c"}, {ID: "d"}}, count: 5, wantNext: true, wantPrev: true},
+		{name: "Last page", query: url.Values{"page": {"3"}}, rows: []row{{ID: "e"}}, count: 5, wantPrev: true},
+		{name: "Empty", query: url.Values{}, rows: []row{}, count: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, page, err := Paginate(validate(t, cursors, tt.query), tt.rows, tt.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (page.Next != "") != tt.wantNext || (page.Prev != "") != tt.wantPrev {
+				t.Errorf("cursors = %+v, want next %v, prev %v", page, tt.wantNext, tt.wantPrev)
+			}
+		})
+	}
+
+	if _, page, _ := Paginate(validate(t, nil, url.Values{}), []row{{ID: "a"}, {ID: "b"}}, 5); page != (Cursors{}) {
+		t.Errorf("cursors without a codec = %+v, want none", page)
+	}
+}
@@ -0,0 +1,300 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Filter operators.
+const (
+	Eq       = "eq"
+	Ne       = "ne"
+	Gt       = "gt"
+	Gte      = "gte"
+	Lt       = "lt"
+	Lte      = "lte"
+	In       = "in"
+	Contains = "contains"
+)
+
+// Comparison operators, for numeric fields.
+var Comparisons = []string{Eq, Ne, Gt, Gte, Lt, Lte, In}
+
+var operatorSQL = map[string]string{
+	Eq:       "%s = ?",
+	Ne:       "%s <> ?",
+	Gt:       "%s > ?",
+	Gte:      "%s >= ?",
+	Lt:       "%s < ?",
+	Lte:      "%s <= ?",
+	In:       "%s IN ?",
+	Contains: "LOWER(%s) LIKE ? ESCAPE '!'",
+}
+
+// FieldType is the type filter values are parsed as.
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Float
+	UUID
+)
+
+// FilterField is a column a list endpoint may be filtered on.
+type FilterField struct {
+	Type      FieldType
+	Operators []string
+}
+
+// FilterFields are the filterable columns of an endpoint, by name.
+type FilterFields map[string]FilterField
+
+const (
+	maxFilterLength     = 1000
+	maxFilterConditions = 10
+	maxInValues         = 100
+)
+
+// Condition is one comparison of a Filter, with a value of the field's type.
+type Condition struct {
+	Field    string
+	Operator string
+	Value    interface{}
+}
+
+// Filter is a conjunction of conditions on whitelisted columns.
+type Filter struct {
+	Conditions []Condition
+}
+
+// Where returns the filter as a parameterized condition for gorm's Where, or "" when it is empty.
+func (f *Filter) Where() (string, []interface{}) {
+	if f == nil || len(f.Conditions) == 0 {
+		return "", nil
+	}
+	clauses := make([]string, len(f.Conditions))
+	args := make([]interface{}, len(f.Conditions))
+	for i, condition := range f.Conditions {
+		clauses[i] = fmt.Sprintf(operatorSQL[condition.Operator], condition.Field)
+		args[i] = condition.Value
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+var bracketParam = regexp.MustCompile(`^([a-z_]+)\[([a-z]+)\]$`)
+
+// ValidateFilter parses the 'filter' query parameter, such as
+// "department_id eq 5f0c... and age gte 30 and name contains 'john d'", and bracket parameters
+// such as "age[gte]=30", into a Filter on fields. Conditions are joined with 'and'; 'in' takes a
+// comma separated list. Values with spaces are quoted with ' or ". It returns nil without conditions.
+func ValidateFilter(r *http.Request, fields FilterFields) (*Filter, error) {
+	filter := &Filter{}
+	query := r.URL.Query()
+
+	if expression := query.Get("filter"); expression != "" {
+		if len(expression) > maxFilterLength {
+			return nil, fmt.Errorf("invalid 'filter' value in query string. Must be at most %d characters. ", maxFilterLength)
+		}
+		conditions, err := parseFilterExpression(expression)
+		if err != nil {
+			return nil, err
+		}
+		for _, condition := range conditions {
+			if err := filter.add(fields, condition.field, condition.operator, condition.values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		match := bracketParam.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		for _, value := range query[key] {
+			values := []string{value}
+			if match[2] == In {
+				values = strings.Split(value, ",")
+			}
+			if err := filter.add(fields, match[1], match[2], values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(filter.Conditions) == 0 {
+		return nil, nil
+	}
+	if len(filter.Conditions) > maxFilterConditions {
+		return nil, fmt.Errorf("invalid filter in query string. At most %d conditions are allowed. ", maxFilterConditions)
+	}
+	return filter, nil
+}
+
+// add checks a condition against fields and appends it with typed values.
+func (f *Filter) add(fields FilterFields, name, operator string, values []string) error {
+	field, ok := fields[name]
+	if !ok {
+		names := make([]string, 0, len(fields))
+		for fieldName := range fields {
+			names = append(names, fieldName)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("invalid filter in query string. '%s' is not filterable, must be one of %s. ", name, strings.Join(names, ", "))
+	}
+	if !slices.Contains(field.Operators, operator) {
+		return fmt.Errorf("invalid filter in query string. '%s' supports %s. ", name, strings.Join(field.Operators, ", "))
+	}
+	if operator != In && len(values) != 1 {
+		return fmt.Errorf("invalid filter in query string. '%s %s' takes a single value. ", name, operator)
+	}
+	if len(values) > maxInValues {
+		return fmt.Errorf("invalid filter in query string. '%s in' takes at most %d values. ", name, maxInValues)
+	}
+
+	typed := make([]interface{}, len(values))
+	for i, value := range values {
+		var err error
+		if typed[i], err = field.Type.parse(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid filter in query string. '%s' %v. ", name, err)
+		}
+	}
+
+	condition := Condition{Field: name, Operator: operator, Value: typed[0]}
+	switch operator {
+	case In:
+		condition.Value = typed
+	case Contains:
+		condition.Value = "%" + escapeLike(strings.ToLower(typed[0].(string))) + "%"
+	}
+	f.Conditions = append(f.Conditions, condition)
+	return nil
+}
+
+func (t FieldType) parse(value string) (interface{}, error) {
+	switch t {
+	case Int:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return i, nil
+	case Float:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return f, nil
+	case UUID:
+		if _, err := uuid.Parse(value); err != nil {
+			return nil, errors.New("must be a UUID")
+		}
+		return value, nil
+	}
+	if value == "" {
+		return nil, errors.New("must not be empty")
+	}
+	return value, nil
+}
+
+// escapeLike escapes the LIKE wildcards with '!', which unlike '\' needs no escaping in any dialect's strings.
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+type rawCondition struct {
+	field    string
+	operator string
+	values   []string
+}
+
+// parseFilterExpression splits "a eq 1 and b in 2,3" into conditions.
+func parseFilterExpression(expression string) ([]rawCondition, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := errors.New("invalid 'filter' value in query string. Must look like \"age gte 30 and name eq 'John Doe'\". ")
+	conditions := []rawCondition{}
+	for i := 0; i < len(tokens); {
+		if len(tokens)-i < 3 || tokens[i].quoted || tokens[i+1].quoted {
+			return nil, invalid
+		}
+		condition := rawCondition{field: tokens[i].text, operator: strings.ToLower(tokens[i+1].text)}
+		i += 2
+
+		for expectValue := true; i < len(tokens); i++ {
+			token := tokens[i]
+			if !token.quoted && strings.EqualFold(token.text, "and") && !expectValue {
+				i++
+				break
+			}
+			if !token.quoted && token.text == "," {
+				if expectValue {
+					return nil, invalid
+				}
+				expectValue = true
+				continue
+			}
+			if !expectValue {
+				return nil, invalid
+			}
+			condition.values = append(condition.values, token.text)
+			expectValue = false
+		}
+		if len(condition.values) == 0 || (i == len(tokens) && strings.EqualFold(tokens[i-1].text, "and") && !tokens[i-1].quoted) {
+			return nil, invalid
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits on spaces and commas, keeping quoted strings whole.
+func tokenize(expression string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(expression); {
+		switch c := expression[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == ',':
+			tokens = append(tokens, token{text: ","})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, errors.New("invalid 'filter' value in query string. Unterminated quote. ")
+			}
+			tokens = append(tokens, token{text: expression[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			end := strings.IndexAny(expression[i:], " \t,")
+			if end < 0 {
+				end = len(expression) - i
+			}
+			tokens = append(tokens, token{text: expression[i : i+end]})
+			i += end
+		}
+	}
+	return tokens, nil
+}
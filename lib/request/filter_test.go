@@ -0,0 +1,113 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var filterFields = FilterFields{
+	"department_id": {Type: UUID, Operators: []string{Eq, In}},
+	"name":          {Type: String, Operators: []string{Eq, Contains}},
+	"age":           {Type: Int, Operators: Comparisons},
+	"salary":        {Type: Float, Operators: Comparisons},
+}
+
+func TestValidateFilter(t *testing.T) {
+	const departmentID = "5f0c6a8e-4b1a-4c8e-9d2b-1f3e5a7c9b0d"
+	tests := []struct {
+		name      string
+		query     url.Values
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{name: "None", query: url.Values{}},
+		{
+			name:      "Expression",
+			query:     url.Values{"filter": {"department_id eq " + departmentID + " and age gte 30 AND salary lt 100000"}},
+			wantWhere: "department_id = ? AND age >= ? AND salary < ?",
+			wantArgs:  []interface{}{departmentID, int64(30), 100000.0},
+		},
+		{
+			name:      "Quoted value",
+			query:     url.Values{"filter": {`name eq "John Doe"`}},
+			wantWhere: "name = ?",
+			wantArgs:  []interface{}{"John Doe"},
+		},
+		{
+			name:      "In",
+			query:     url.Values{"filter": {"age in 30, 40,50"}},
+			wantWhere: "age IN ?",
+			wantArgs:  []interface{}{[]interface{}{int64(30), int64(40), int64(50)}},
+		},
+		{
+			name:      "Contains escapes wildcards",
+			query:     url.Values{"filter": {"name contains '50%_Off!'"}},
+			wantWhere: "LOWER(name) LIKE ? ESCAPE '!'",
+			wantArgs:  []interface{}{"%50!%!_off!!%"},
+		},
+		{
+			name:      "Brackets",
+			query:     url.Values{"salary[lt]": {"100000"}, "age[gte]": {"30"}, "department_id[in]": {departmentID}, "page": {"2"}},
+			wantWhere: "age >= ? AND department_id IN ? AND salary < ?",
+			wantArgs:  []interface{}{int64(30), []interface{}{departmentID}, 100000.0},
+		},
+		{
+			name:      "Expression and brackets",
+			query:     url.Values{"filter": {"name eq 'a'"}, "age[lt]": {"20"}},
+			wantWhere: "name = ? AND age < ?",
+			wantArgs:  []interface{}{"a", int64(20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query.Encode(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, err := ValidateFilter(req, filterFields)
+			if err != nil {
+				t.Fatalf("ValidateFilter() error = %v", err)
+			}
+			where, args := filter.Where()
+			if where != tt.wantWhere || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Where() = %q, %#v, want %q, %#v", where, args, tt.wantWhere, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestValidateFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+	}{
+		{name: "Unknown field", query: url.Values{"filter": {"email eq a@b.c"}}},
+		{name: "Column injection", query: url.Values{"filter": {"1=1; DROP TABLE user eq 1"}}},
+		{name: "Operator not allowed", query: url.Values{"filter": {"name gt 'a'"}}},
+		{name: "Unknown operator", query: url.Values{"age[like]": {"3"}}},
+		{name: "Not an integer", query: url.Values{"filter": {"age gte thirty"}}},
+		{name: "Not a number", query: url.Values{"salary[lt]": {"lots"}}},
+		{name: "Not a UUID", query: url.Values{"filter": {"department_id eq 42"}}},
+		{name: "Empty string", query: url.Values{"filter": {"name eq ''"}}},
+		{name: "Several values", query: url.Values{"filter": {"age eq 1, 2"}}},
+		{name: "Missing value", query: url.Values{"filter": {"age gte"}}},
+		{name: "Missing and", query: url.Values{"filter": {"age gte 30 salary lt 5"}}},
+		{name: "Trailing and", query: url.Values{"filter": {"age gte 30 and"}}},
+		{name: "Empty in value", query: url.Values{"filter": {"age in 1,,2"}}},
+		{name: "Unterminated quote", query: url.Values{"filter": {"name eq 'John"}}},
+		{name: "Quoted field", query: url.Values{"filter": {"'age' eq 1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query.Encode(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ValidateFilter(req, filterFields); err == nil {
+				t.Errorf("ValidateFilter() error = nil, want an error")
+			}
+		})
+	}
+}
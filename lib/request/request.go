@@ -1,21 +1,59 @@
 package request
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
 )
 
+// maxBodyBytes caps the size of JSON request bodies.
+const maxBodyBytes = 1 << 20
+
 // QueryParams represents query parameters for pagination and sorting.
 type QueryParams struct {
-	Limit   int
-	Page    int
+	Limit int
+	// Page is the offset of the first row; always 0 in cursor mode.
+	Page int
+	// OrderBy is an ORDER BY clause built only from sortable columns and id, such as
+	// "COALESCE(age, 0) ASC, name DESC, id ASC".
 	OrderBy string
 	Sort    string
+	// Keyset is set in cursor mode and must be applied as a WHERE condition instead of the offset.
+	Keyset *Keyset
+	// Filter restricts the rows; set by the caller from ValidateFilter, nil for all rows.
+	Filter *Filter
+
+	orderby string
+	fields  []sortField
+	cursors *CursorCodec
+}
+
+// FetchLimit is the number of rows to fetch: one more than Limit in cursor mode, to learn whether
+// there is a next page.
+func (q QueryParams) FetchLimit() int {
+	if q.Keyset != nil {
+		return q.Limit + 1
+	}
+	return q.Limit
 }
 
 // ValidateQueryString validates query string parameters and returns QueryParams.
-func ValidateQueryString(r *http.Request, defaultLimit string, defaultPage string, defaultOrderby string, defaultSort string) (QueryParams, error) {
+// The 'orderby' parameter is a comma separated list of columns from sortable, each optionally
+// prefixed with '-' to sort descending, such as "age,-salary". Columns without a prefix sort
+// in the 'sort' direction, and id breaks ties.
+//
+// With cursors, a 'cursor' parameter issued by Paginate selects keyset pagination: the ordering
+// comes from the cursor and 'page', 'orderby' and 'sort' are ignored. A nil cursors rejects it.
+func ValidateQueryString(r *http.Request, defaultLimit string, defaultPage string, defaultOrderby string, defaultSort string, sortable []SortColumn, cursors *CursorCodec) (QueryParams, error) {
 	params := QueryParams{}
 
 	// Extract query parameters
@@ -23,15 +61,19 @@ func ValidateQueryString(r *http.Request, defaultLimit string, defaultPage strin
 	if err != nil {
 		return params, err
 	}
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		return getCursorParams(value, limit, sortable, cursors)
+	}
 	page, err := getPage(r, defaultPage)
 	if err != nil {
 		return params, err
 	}
-	orderby, err := getOrderBy(r, defaultOrderby)
+	sort, err := getSort(r, defaultSort)
 	if err != nil {
 		return params, err
 	}
-	sort, err := getSort(r, defaultSort)
+	orderby := getOrderBy(r, defaultOrderby)
+	fields, err := parseOrderBy(orderby, sort, sortable)
 	if err != nil {
 		return params, err
 	}
@@ -43,8 +85,36 @@ func ValidateQueryString(r *http.Request, defaultLimit string, defaultPage strin
 	return QueryParams{
 		Limit:   limit,
 		Page:    page,
-		OrderBy: orderby,
+		OrderBy: orderClause(fields, false),
 		Sort:    sort,
+		orderby: orderby,
+		fields:  fields,
+		cursors: cursors,
+	}, nil
+}
+
+// getCursorParams decodes a cursor and checks its ordering against sortable again, since what
+// the caller may sort by can change between pages.
+func getCursorParams(value string, limit int, sortable []SortColumn, cursors *CursorCodec) (QueryParams, error) {
+	if cursors == nil {
+		return QueryParams{}, errors.New("'cursor' is not supported by this endpoint. ")
+	}
+	cur, err := cursors.decode(value)
+	if err != nil {
+		return QueryParams{}, err
+	}
+	fields, err := parseOrderBy(cur.OrderBy, cur.Sort, sortable)
+	if err != nil || len(fields) != len(cur.Values) {
+		return QueryParams{}, ErrInvalidCursor
+	}
+	return QueryParams{
+		Limit:   limit,
+		OrderBy: orderClause(fields, cur.Backward),
+		Sort:    cur.Sort,
+		Keyset:  newKeyset(fields, cur.Values, cur.Backward),
+		orderby: cur.OrderBy,
+		fields:  fields,
+		cursors: cursors,
 	}, nil
 }
 
@@ -66,17 +136,95 @@ func getPage(r *http.Request, defaultPage string) (int, error) {
 	return parseInt(pages[0])
 }
 
-// getOrderBy retrieves and validates the 'orderby' query parameter.
-func getOrderBy(r *http.Request, defaultOrderby string) (string, error) {
+// getOrderBy retrieves the 'orderby' query parameter.
+func getOrderBy(r *http.Request, defaultOrderby string) string {
 	orderbys, ok := r.URL.Query()["orderby"]
 	if !ok || len(orderbys[0]) < 1 {
-		return defaultOrderby, nil
+		return defaultOrderby
+	}
+	return orderbys[0]
+}
+
+// SortColumn is a column clients may sort by. NullAs is the SQL literal that NULL sorts as in a
+// nullable column, such as 0 or an empty string. It must be the value rows report for NULL, usually the zero
+// value of their model field, so that keyset pagination neither skips nor repeats those rows.
+// NOT NULL columns leave it empty.
+type SortColumn struct {
+	Name   string
+	NullAs string
+}
+
+// NotNull returns the sort columns of NOT NULL columns.
+func NotNull(names ...string) []SortColumn {
+	columns := make([]SortColumn, len(names))
+	for i, name := range names {
+		columns[i] = SortColumn{Name: name}
+	}
+	return columns
+}
+
+// expression is what the column is sorted and compared by.
+func (c SortColumn) expression() string {
+	if c.NullAs == "" {
+		return c.Name
+	}
+	return "COALESCE(" + c.Name + ", " + c.NullAs + ")"
+}
+
+// sortField is a column of an ORDER BY clause. column names its key in the rows, and expression is
+// what the database sorts by.
+type sortField struct {
+	column     string
+	expression string
+	desc       bool
+}
+
+// tiebreaker keeps the order of rows with equal sort values stable, which keyset pagination needs.
+const tiebreaker = "id"
+
+// parseOrderBy parses a list such as "age,-salary". Only columns in sortable are accepted, so the
+// clause built from it never contains client supplied SQL. id is appended when missing.
+func parseOrderBy(orderby string, sort string, sortable []SortColumn) ([]sortField, error) {
+	columns := strings.Split(orderby, ",")
+	fields := make([]sortField, 0, len(columns)+1)
+	seen := make(map[string]bool, len(columns)+1)
+	for _, column := range columns {
+		field := sortField{column: strings.TrimSpace(column), desc: sort == "desc"}
+		if name, ok := strings.CutPrefix(field.column, "-"); ok {
+			field.column, field.desc = name, true
+		}
+		i := slices.IndexFunc(sortable, func(c SortColumn) bool { return c.Name == field.column })
+		if i < 0 {
+			names := make([]string, len(sortable))
+			for j, c := range sortable {
+				names[j] = c.Name
+			}
+			return nil, fmt.Errorf("invalid 'orderby' value in query string. Must be a comma separated list of %s, each optionally prefixed with '-'. ", strings.Join(names, ", "))
+		}
+		field.expression = sortable[i].expression()
+		if seen[field.column] {
+			return nil, fmt.Errorf("invalid 'orderby' value in query string. '%s' is listed more than once. ", field.column)
+		}
+		seen[field.column] = true
+		fields = append(fields, field)
+	}
+	if !seen[tiebreaker] {
+		fields = append(fields, sortField{column: tiebreaker, expression: tiebreaker})
 	}
-	orderby := orderbys[0]
-	if _, err := strconv.Atoi(orderby); err == nil {
-		return "", errors.New("invalid 'orderby' value in query string. Must be a string. ")
+	return fields, nil
+}
+
+// orderClause returns "COALESCE(age, 0) ASC, name DESC, id ASC" for fields, with every direction flipped when reverse.
+func orderClause(fields []sortField, reverse bool) string {
+	clauses := make([]string, len(fields))
+	for i, field := range fields {
+		direction := "ASC"
+		if field.desc != reverse {
+			direction = "DESC"
+		}
+		clauses[i] = field.expression + " " + direction
 	}
-	return orderby, nil
+	return strings.Join(clauses, ", ")
 }
 
 // getSort retrieves and validates the 'sort' query parameter.
@@ -103,3 +251,46 @@ func parseInt(str string) (int, error) {
 	}
 	return i, nil
 }
+
+// DecodeJSONBody decodes the JSON request body into dst, rejecting unknown fields.
+func DecodeJSONBody(r *http.Request, dst interface{}) error {
+	if r.Body == nil {
+		return errors.New("request body is required. ")
+	}
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("request body is required. ")
+		}
+		return fmt.Errorf("invalid request body: %v. ", err)
+	}
+	if decoder.More() {
+		return errors.New("request body must contain a single JSON object. ")
+	}
+	return nil
+}
+
+// ValidatePathUUID retrieves the URL path parameter key and checks that it is a UUID.
+func ValidatePathUUID(r *http.Request, key string) (string, error) {
+	value := chi.URLParam(r, key)
+	if _, err := uuid.Parse(value); err != nil {
+		return "", fmt.Errorf("invalid '%s' value in path. Must be a UUID. ", key)
+	}
+	return value, nil
+}
+
+// maxSearchLength bounds search terms, which are matched against every searched column.
+const maxSearchLength = 100
+
+// ValidateSearchTerm retrieves the 'q' query parameter, which must not be blank.
+func ValidateSearchTerm(r *http.Request) (string, error) {
+	term := strings.TrimSpace(r.URL.Query().Get("q"))
+	if term == "" {
+		return "", errors.New("'q' is required in query string. ")
+	}
+	if utf8.RuneCountInString(term) > maxSearchLength {
+		return "", fmt.Errorf("invalid 'q' value in query string. Must be at most %d characters. ", maxSearchLength)
+	}
+	return term, nil
+}
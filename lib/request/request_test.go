@@ -1,8 +1,12 @@
 package request
 
 import (
+	"context"
 	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/go-chi/chi"
 )
 
 func TestValidateQueryString(t *testing.T) {
@@ -26,7 +30,7 @@ func TestValidateQueryString(t *testing.T) {
 			want: QueryParams{
 				Limit:   10,
 				Page:    0,
-				OrderBy: "name",
+				OrderBy: "name ASC, id ASC",
 				Sort:    "asc",
 			},
 			wantErr: false,
@@ -41,11 +45,36 @@ func TestValidateQueryString(t *testing.T) {
 			want: QueryParams{
 				Limit:   20,
 				Page:    20,
-				OrderBy: "id",
+				OrderBy: "id DESC",
 				Sort:    "desc",
 			},
 			wantErr: false,
 		},
+		{
+			name:           "multi column orderby",
+			queryString:    "orderby=age,-salary",
+			defaultLimit:   "10",
+			defaultPage:    "1",
+			defaultOrderby: "name",
+			defaultSort:    "asc",
+			want: QueryParams{
+				Limit:   10,
+				Page:    0,
+				OrderBy: "age ASC, salary DESC, id ASC",
+				Sort:    "asc",
+			},
+			wantErr: false,
+		},
+		{
+			name:           "orderby not in whitelist",
+			queryString:    "orderby=password",
+			defaultLimit:   "10",
+			defaultPage:    "1",
+			defaultOrderby: "name",
+			defaultSort:    "asc",
+			want:           QueryParams{},
+			wantErr:        true,
+		},
 		{
 			name:           "invalid limit",
 			queryString:    "limit=abc",
@@ -95,7 +124,7 @@ func TestValidateQueryString(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			got, err := ValidateQueryString(req, tt.defaultLimit, tt.defaultPage, tt.defaultOrderby, tt.defaultSort)
+			got, err := ValidateQueryString(req, tt.defaultLimit, tt.defaultPage, tt.defaultOrderby, tt.defaultSort, NotNull("id", "name", "age", "salary"), nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateQueryString() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -224,28 +253,18 @@ func TestGetOrderBy(t *testing.T) {
 		queryString    string
 		defaultOrderby string
 		want           string
-		wantErr        bool
 	}{
 		{
 			name:           "default value",
 			queryString:    "",
 			defaultOrderby: "name",
 			want:           "name",
-			wantErr:        false,
 		},
 		{
 			name:           "custom value",
-			queryString:    "orderby=id",
-			defaultOrderby: "name",
-			want:           "id",
-			wantErr:        false,
-		},
-		{
-			name:           "invalid value",
-			queryString:    "orderby=123",
+			queryString:    "orderby=-age,id",
 			defaultOrderby: "name",
-			want:           "",
-			wantErr:        true,
+			want:           "-age,id",
 		},
 	}
 
@@ -256,13 +275,91 @@ func TestGetOrderBy(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			got, err := getOrderBy(req, tt.defaultOrderby)
+			if got := getOrderBy(req, tt.defaultOrderby); got != tt.want {
+				t.Errorf("getOrderBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	sortable := NotNull("id", "name", "age", "salary")
+	tests := []struct {
+		name    string
+		orderby string
+		sort    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "single column",
+			orderby: "name",
+			sort:    "asc",
+			want:    "name ASC, id ASC",
+			wantErr: false,
+		},
+		{
+			name:    "id already listed",
+			orderby: "-id",
+			sort:    "asc",
+			want:    "id DESC",
+			wantErr: false,
+		},
+		{
+			name:    "sort applies to unprefixed columns",
+			orderby: "age,-salary,name",
+			sort:    "desc",
+			want:    "age DESC, salary DESC, name DESC, id ASC",
+			wantErr: false,
+		},
+		{
+			name:    "spaces around columns",
+			orderby: " -age , id",
+			sort:    "asc",
+			want:    "age DESC, id ASC",
+			wantErr: false,
+		},
+		{
+			name:    "number",
+			orderby: "123",
+			sort:    "asc",
+			wantErr: true,
+		},
+		{
+			name:    "sql injection",
+			orderby: "name; DROP TABLE user",
+			sort:    "asc",
+			wantErr: true,
+		},
+		{
+			name:    "expression",
+			orderby: "(CASE WHEN salary > 100000 THEN 1 ELSE 0 END)",
+			sort:    "asc",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate column",
+			orderby: "age,-age",
+			sort:    "asc",
+			wantErr: true,
+		},
+		{
+			name:    "empty column",
+			orderby: "age,,name",
+			sort:    "asc",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := parseOrderBy(tt.orderby, tt.sort, sortable)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("getOrderBy() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("parseOrderBy() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("getOrderBy() = %v, want %v", got, tt.want)
+			if got := orderClause(fields, false); !tt.wantErr && got != tt.want {
+				t.Errorf("orderClause() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -359,3 +456,137 @@ func TestParseInt(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeJSONBody(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "valid body",
+			body:    `{"name":"Alice"}`,
+			want:    "Alice",
+			wantErr: false,
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			body:    `{"name":"Alice","role":"admin"}`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			body:    `{"name":"Alice"}{"name":"Bob"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed json",
+			body:    `{"name":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got payload
+			err = DecodeJSONBody(req, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeJSONBody() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Name != tt.want {
+				t.Errorf("DecodeJSONBody().Name = %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePathUUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{
+			name:    "valid uuid",
+			value:   "520349ca-a915-487d-ba21-c9740dd08aa7",
+			wantErr: false,
+		},
+		{
+			name:    "invalid uuid",
+			value:   "abc",
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			value:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.value)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			got, err := ValidatePathUUID(req, "id")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePathUUID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.value {
+				t.Errorf("ValidatePathUUID() = %v, want %v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestValidateSearchTerm(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid term", query: "q=+jo%20smith+", want: "jo smith"},
+		{name: "missing term", query: "", wantErr: true},
+		{name: "blank term", query: "q=%20%20", wantErr: true},
+		{name: "too long", query: "q=" + strings.Repeat("a", 101), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/?"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ValidateSearchTerm(req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSearchTerm() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ValidateSearchTerm() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
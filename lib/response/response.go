@@ -46,16 +46,23 @@ func (e *ErrorResponseDecorator) Handle(w http.ResponseWriter, class interface{}
 }
 
 // SuccessResponseListDecorator handles list responses with additional metadata.
+// The cursors of the neighbouring pages are added when not empty.
 type SuccessResponseListDecorator struct {
 	ResponseHandler
 }
 
-func (s *SuccessResponseListDecorator) Handle(w http.ResponseWriter, class interface{}, offset string, limit string, count string) {
+func (s *SuccessResponseListDecorator) Handle(w http.ResponseWriter, class interface{}, offset string, limit string, count string, nextCursor string, prevCursor string) {
 	tempResponse := make(map[string]interface{})
 	tempResponse["count"] = count
 	tempResponse["offset"] = offset
 	tempResponse["limit"] = limit
 	tempResponse["list"] = class
+	if nextCursor != "" {
+		tempResponse["next_cursor"] = nextCursor
+	}
+	if prevCursor != "" {
+		tempResponse["prev_cursor"] = prevCursor
+	}
 
 	s.ResponseHandler.Handle(w, tempResponse, http.StatusOK)
 }
@@ -73,9 +80,9 @@ func SuccessResponseHelper(w http.ResponseWriter, class interface{}, httpStatus
 }
 
 // SuccessResponseList function
-func SuccessResponseList(w http.ResponseWriter, class interface{}, offset string, limit string, count string) {
+func SuccessResponseList(w http.ResponseWriter, class interface{}, offset string, limit string, count string, nextCursor string, prevCursor string) {
 	decorator := &SuccessResponseListDecorator{&BaseResponseHandler{}}
-	decorator.Handle(w, class, offset, limit, count)
+	decorator.Handle(w, class, offset, limit, count, nextCursor, prevCursor)
 }
 
 func errorResponse(message string) []byte {
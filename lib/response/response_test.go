@@ -98,7 +98,7 @@ func TestErrorResponseDecorator(t *testing.T) {
 func TestSuccessResponseListDecorator(t *testing.T) {
 	w := httptest.NewRecorder()
 	decorator := &SuccessResponseListDecorator{&BaseResponseHandler{}}
-	decorator.Handle(w, []string{"Item1", "Item2"}, "0", "10", "2")
+	decorator.Handle(w, []string{"Item1", "Item2"}, "0", "10", "2", "", "")
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
@@ -199,7 +199,7 @@ func TestSuccessResponseHelper(t *testing.T) {
 
 func TestSuccessResponseList(t *testing.T) {
 	w := httptest.NewRecorder()
-	SuccessResponseList(w, []string{"Item1", "Item2"}, "0", "10", "2")
+	SuccessResponseList(w, []string{"Item1", "Item2"}, "0", "10", "2", "", "")
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
@@ -239,3 +239,21 @@ func TestSuccessResponseList(t *testing.T) {
 		t.Errorf("Expected second item to be 'Item2', got '%v'", response["data"].(map[string]interface{})["list"].([]interface{})[1])
 	}
 }
+
+func TestSuccessResponseList_Cursors(t *testing.T) {
+	w := httptest.NewRecorder()
+	SuccessResponseList(w, []string{"Item1"}, "0", "1", "2", "next", "")
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	data := response["data"].(map[string]interface{})
+
+	if data["next_cursor"] != "next" {
+		t.Errorf("Expected next_cursor to be 'next', got '%v'", data["next_cursor"])
+	}
+	if _, ok := data["prev_cursor"]; ok {
+		t.Errorf("Expected no prev_cursor, got '%v'", data["prev_cursor"])
+	}
+}
@@ -0,0 +1,192 @@
+// Package retry provides a gRPC client interceptor that retries failed unary calls with
+// exponential backoff and jitter, and optionally hedges them by racing parallel attempts.
+//
+// Only the methods listed in Policy.Methods are retried, because retrying a call that is not
+// idempotent, such as awarding points, could apply it twice.
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/syedomair/backend-microservices/lib/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var retryAttempts = metrics.MustRegister(prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "grpc_client_retry_attempts_total",
+		Help: "Extra attempts made by the retry interceptor",
+	},
+	[]string{"method", "kind"},
+))
+
+// Policy configures UnaryClientInterceptor.
+type Policy struct {
+	// MaxAttempts includes the first attempt; 1 disables retries and hedging.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter spreads each backoff uniformly by this fraction, e.g. 0.2 for ±20%.
+	Jitter float64
+	// PerAttemptTimeout bounds each attempt within the caller's deadline; 0 means no extra bound.
+	PerAttemptTimeout time.Duration
+	// HedgingDelay, when positive, starts another attempt if none has answered after this delay
+	// instead of waiting for a failure.
+	HedgingDelay   time.Duration
+	RetryableCodes []codes.Code
+	// Methods are full method names, such as "/point.PointServer/GetUserPoints", that may be retried.
+	Methods []string
+}
+
+// DefaultPolicy retries Unavailable and DeadlineExceeded up to 3 attempts without hedging.
+func DefaultPolicy(methods ...string) Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableCodes: []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+		Methods:        methods,
+	}
+}
+
+// UnaryClientInterceptor applies p to the unary calls of the methods it lists.
+func UnaryClientInterceptor(p Policy) grpc.UnaryClientInterceptor {
+	methods := make(map[string]struct{}, len(p.Methods))
+	for _, method := range p.Methods {
+		methods[method] = struct{}{}
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := methods[method]; !ok || p.MaxAttempts <= 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		call := func(ctx context.Context, reply interface{}) error {
+			if p.PerAttemptTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, p.PerAttemptTimeout)
+				defer cancel()
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if msg, ok := reply.(proto.Message); ok && p.HedgingDelay > 0 {
+			return p.hedge(ctx, method, msg, call)
+		}
+		return p.retry(ctx, method, reply, call)
+	}
+}
+
+func (p Policy) retry(ctx context.Context, method string, reply interface{}, call func(context.Context, interface{}) error) error {
+	for attempt := 1; ; attempt++ {
+		err := call(ctx, reply)
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(ctx, err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		retryAttempts.WithLabelValues(method, "retry").Inc()
+	}
+}
+
+type result struct {
+	reply proto.Message
+	err   error
+}
+
+// hedge starts an attempt, then another one whenever HedgingDelay passes without an answer or an
+// attempt fails with a retryable code, up to MaxAttempts. The first success wins and cancels the rest.
+func (p Policy) hedge(ctx context.Context, method string, reply proto.Message, call func(context.Context, interface{}) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, p.MaxAttempts)
+	launched, pending := 0, 0
+	launch := func() {
+		if launched > 0 {
+			retryAttempts.WithLabelValues(method, "hedge").Inc()
+		}
+		launched++
+		pending++
+		attemptReply := reply.ProtoReflect().New().Interface()
+		go func() {
+			results <- result{reply: attemptReply, err: call(ctx, attemptReply)}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(p.HedgingDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, res.reply)
+				return nil
+			}
+			if !p.retryable(ctx, res.err) {
+				return res.err
+			}
+			if launched < p.MaxAttempts {
+				launch()
+				timer.Reset(p.HedgingDelay)
+			} else if pending == 0 {
+				return res.err
+			}
+		case <-timer.C:
+			if launched < p.MaxAttempts {
+				launch()
+				timer.Reset(p.HedgingDelay)
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// retryable reports whether err has a retryable code while the caller still wants an answer.
+func (p Policy) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	code := status.Code(err)
+	for _, retryableCode := range p.RetryableCodes {
+		if code == retryableCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the jittered wait before attempt+1.
+func (p Policy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(backoff)
+}
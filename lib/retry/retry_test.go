@@ -0,0 +1,166 @@
+package retry
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// flakyHealthServer answers Check with the configured error for the first failures calls
+// and delays every answer by the delay returned for that call.
+type flakyHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	calls    atomic.Int32
+	failures int32
+	code     codes.Code
+	delay    func(call int32) time.Duration
+}
+
+func (s *flakyHealthServer) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	call := s.calls.Add(1)
+	if s.delay != nil {
+		select {
+		case <-time.After(s.delay(call)):
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if call <= s.failures {
+		return nil, status.Error(s.code, "flaky")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func newClient(t *testing.T, srv healthpb.HealthServer, policy Policy) healthpb.HealthClient {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, srv)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(policy)))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func testPolicy() Policy {
+	policy := DefaultPolicy(checkMethod)
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestUnaryClientInterceptor_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		code      codes.Code
+		methods   []string
+		wantCode  codes.Code
+		wantCalls int32
+	}{
+		{name: "Recovers from unavailable", failures: 2, code: codes.Unavailable, methods: []string{checkMethod}, wantCode: codes.OK, wantCalls: 3},
+		{name: "Gives up after max attempts", failures: 5, code: codes.Unavailable, methods: []string{checkMethod}, wantCode: codes.Unavailable, wantCalls: 3},
+		{name: "Does not retry other codes", failures: 1, code: codes.InvalidArgument, methods: []string{checkMethod}, wantCode: codes.InvalidArgument, wantCalls: 1},
+		{name: "Does not retry unlisted methods", failures: 1, code: codes.Unavailable, methods: nil, wantCode: codes.Unavailable, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &flakyHealthServer{failures: tt.failures, code: tt.code}
+			policy := testPolicy()
+			policy.Methods = tt.methods
+			client := newClient(t, srv, policy)
+
+			_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantCalls, srv.calls.Load())
+		})
+	}
+}
+
+func TestUnaryClientInterceptor_PerAttemptTimeout(t *testing.T) {
+	srv := &flakyHealthServer{delay: func(call int32) time.Duration {
+		if call == 1 {
+			return time.Second
+		}
+		return 0
+	}}
+	policy := testPolicy()
+	policy.PerAttemptTimeout = 20 * time.Millisecond
+	client := newClient(t, srv, policy)
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, int32(2), srv.calls.Load())
+}
+
+func TestUnaryClientInterceptor_Hedging(t *testing.T) {
+	srv := &flakyHealthServer{delay: func(call int32) time.Duration {
+		if call == 1 {
+			return time.Second
+		}
+		return 0
+	}}
+	policy := testPolicy()
+	policy.HedgingDelay = 20 * time.Millisecond
+	client := newClient(t, srv, policy)
+
+	start := time.Now()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Expected the hedged attempt to answer first")
+}
+
+func TestUnaryClientInterceptor_CallerDeadline(t *testing.T) {
+	srv := &flakyHealthServer{failures: 100, code: codes.Unavailable}
+	policy := testPolicy()
+	policy.MaxAttempts = 100
+	policy.InitialBackoff = 50 * time.Millisecond
+	policy.MaxBackoff = 50 * time.Millisecond
+	client := newClient(t, srv, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+
+	assert.Error(t, err)
+	assert.Less(t, srv.calls.Load(), int32(5))
+}
+
+func TestPolicy_backoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.backoff(3))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/response"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	ClaimsKey      contextKey = "claims"
+	PermissionsKey contextKey = "permissions"
+)
+
+// Authenticator verifies a bearer token and returns its claims.
+type Authenticator interface {
+	Verify(token string) (*auth.Claims, error)
+}
+
+// Option configures NewRouter.
+type Option func(*options)
+
+type options struct {
+	authenticator    Authenticator
+	rolePermissions  auth.RolePermissions
+	rateLimitStore   RateLimitStore
+	defaultRateLimit *RateLimit
+	apiKeys          apiKeySet
+	metrics          MetricsConfig
+	tracerProvider   trace.TracerProvider
+}
+
+// WithAuthenticator requires a valid bearer token on every /v1 route that is not Public.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithRolePermissions replaces auth.DefaultRolePermissions as the mapping from token roles to permissions.
+func WithRolePermissions(rolePermissions auth.RolePermissions) Option {
+	return func(o *options) {
+		o.rolePermissions = rolePermissions
+	}
+}
+
+// ClaimsFromContext returns the claims the auth middleware verified for the request.
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(ClaimsKey).(*auth.Claims)
+	return claims, ok
+}
+
+// HasPermission reports whether the caller was granted permission. Without an Authenticator every
+// permission is granted; on Public routes of a router with one, none is.
+func HasPermission(ctx context.Context, permission string) bool {
+	permissions, _ := ctx.Value(PermissionsKey).(auth.Permissions)
+	return permissions.Has(permission)
+}
+
+// authMiddleware rejects requests without a valid bearer token with 401 and stores the claims of valid ones in the context.
+func authMiddleware(logger *zap.Logger, authenticator Authenticator, rolePermissions auth.RolePermissions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methodName := "authMiddleware"
+
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				response.ErrorResponseHelper(methodName, w, "missing bearer token", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := authenticator.Verify(token)
+			if err != nil {
+				logger.Info("token rejected", zap.String("path", r.URL.Path), zap.Error(err))
+				message := "invalid token"
+				if errors.Is(err, auth.ErrTokenExpired) {
+					message = "token expired"
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				response.ErrorResponseHelper(methodName, w, message, http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ClaimsKey, claims)
+			ctx = context.WithValue(ctx, PermissionsKey, rolePermissions.Grants(claims))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// grantAllMiddleware grants every permission, keeping routes usable while authentication is disabled.
+func grantAllMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), PermissionsKey, auth.Permissions{auth.AllPermissions: {}})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// permissionMiddleware rejects callers missing any of permissions with 403.
+func permissionMiddleware(logger *zap.Logger, permissions []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methodName := "permissionMiddleware"
+
+			for _, permission := range permissions {
+				if !HasPermission(r.Context(), permission) {
+					subject := ""
+					if claims, ok := ClaimsFromContext(r.Context()); ok {
+						subject = claims.Subject
+					}
+					logger.Info("permission denied", zap.String("path", r.URL.Path), zap.String("subject", subject), zap.String("permission", permission))
+					response.ErrorResponseHelper(methodName, w, "missing permission "+permission, http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
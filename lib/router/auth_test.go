@@ -0,0 +1,179 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"go.uber.org/zap"
+)
+
+func TestNewRouter_Authentication(t *testing.T) {
+	secret := []byte("test-secret")
+	keys := auth.NewKeySet()
+	keys.AddHMAC("", secret)
+
+	var gotClaims *auth.Claims
+	routes := []EndPoint{
+		{
+			Name:    "Private",
+			Method:  Get,
+			Pattern: "/private",
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				gotClaims, _ = ClaimsFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		{
+			Name:        "Public",
+			Method:      Get,
+			Pattern:     "/public",
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+			Public:      true,
+		},
+	}
+	router := NewRouter(zap.NewNop(), routes, WithAuthenticator(auth.NewVerifier(keys, "", "")))
+
+	sign := func(expiresAt time.Time, key []byte) string {
+		token, err := auth.Sign(&auth.Claims{Subject: "user-1", Roles: []string{"admin"}, Tenant: "acme", ExpiresAt: expiresAt.Unix()}, auth.HS256, "", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "Valid token", path: "/v1/private", authorization: "Bearer " + sign(time.Now().Add(time.Hour), secret), wantStatus: http.StatusOK},
+		{name: "Missing token", path: "/v1/private", wantStatus: http.StatusUnauthorized},
+		{name: "Wrong scheme", path: "/v1/private", authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+		{name: "Expired token", path: "/v1/private", authorization: "Bearer " + sign(time.Now().Add(-time.Hour), secret), wantStatus: http.StatusUnauthorized},
+		{name: "Bad signature", path: "/v1/private", authorization: "Bearer " + sign(time.Now().Add(time.Hour), []byte("other")), wantStatus: http.StatusUnauthorized},
+		{name: "Public route", path: "/v1/public", wantStatus: http.StatusOK},
+		{name: "Health check", path: "/health", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotClaims = nil
+			req := httptest.NewRequest(Get, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("expected a WWW-Authenticate header")
+			}
+			if tt.name == "Valid token" && (gotClaims == nil || gotClaims.Subject != "user-1" || gotClaims.Tenant != "acme") {
+				t.Errorf("claims in context = %+v", gotClaims)
+			}
+		})
+	}
+}
+
+func TestNewRouter_WithoutAuthenticator(t *testing.T) {
+	routes := []EndPoint{
+		{Name: "Private", Method: Get, Pattern: "/private", HandlerFunc: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }},
+	}
+	router := NewRouter(zap.NewNop(), routes)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(Get, "/v1/private", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestNewRouter_Permissions(t *testing.T) {
+	secret := []byte("test-secret")
+	keys := auth.NewKeySet()
+	keys.AddHMAC("", secret)
+
+	var canReadSalary bool
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		canReadSalary = HasPermission(r.Context(), auth.PermUsersReadSalary)
+		w.WriteHeader(http.StatusOK)
+	}
+	routes := []EndPoint{
+		{Name: "Read", Method: Get, Pattern: "/users", HandlerFunc: ok, Permissions: []string{auth.PermUsersRead}},
+		{Name: "Write", Method: Post, Pattern: "/users", HandlerFunc: ok, Permissions: []string{auth.PermUsersWrite}},
+		{Name: "Open", Method: Get, Pattern: "/open", HandlerFunc: ok},
+	}
+	router := NewRouter(zap.NewNop(), routes, WithAuthenticator(auth.NewVerifier(keys, "", "")))
+
+	sign := func(roles ...string) string {
+		token, err := auth.Sign(&auth.Claims{Subject: "user-1", Roles: roles, ExpiresAt: time.Now().Add(time.Hour).Unix()}, auth.HS256, "", secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name              string
+		method            string
+		path              string
+		authorization     string
+		wantStatus        int
+		wantCanReadSalary bool
+	}{
+		{name: "Viewer reads", method: Get, path: "/v1/users", authorization: sign("viewer"), wantStatus: http.StatusOK},
+		{name: "Viewer writes", method: Post, path: "/v1/users", authorization: sign("viewer"), wantStatus: http.StatusForbidden},
+		{name: "HR reads salaries", method: Get, path: "/v1/users", authorization: sign("hr"), wantStatus: http.StatusOK, wantCanReadSalary: true},
+		{name: "Admin writes", method: Post, path: "/v1/users", authorization: sign("admin"), wantStatus: http.StatusOK, wantCanReadSalary: true},
+		{name: "Permission as role", method: Post, path: "/v1/users", authorization: sign(auth.PermUsersWrite), wantStatus: http.StatusOK},
+		{name: "Wildcard role is not a permission", method: Post, path: "/v1/users", authorization: sign(auth.AllPermissions), wantStatus: http.StatusForbidden},
+		{name: "No roles", method: Get, path: "/v1/users", authorization: sign(), wantStatus: http.StatusForbidden},
+		{name: "No required permissions", method: Get, path: "/v1/open", authorization: sign(), wantStatus: http.StatusOK},
+		{name: "Unauthenticated", method: Get, path: "/v1/users", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canReadSalary = false
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if canReadSalary != tt.wantCanReadSalary {
+				t.Errorf("HasPermission(users:read_salary) = %v, want %v", canReadSalary, tt.wantCanReadSalary)
+			}
+		})
+	}
+}
+
+func TestNewRouter_PermissionsWithoutAuthenticator(t *testing.T) {
+	var canReadSalary bool
+	routes := []EndPoint{
+		{Name: "Write", Method: Post, Pattern: "/users", Permissions: []string{auth.PermUsersWrite}, HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			canReadSalary = HasPermission(r.Context(), auth.PermUsersReadSalary)
+			w.WriteHeader(http.StatusOK)
+		}},
+	}
+	router := NewRouter(zap.NewNop(), routes)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(Post, "/v1/users", nil))
+
+	if rr.Code != http.StatusOK || !canReadSalary {
+		t.Errorf("status = %d, canReadSalary = %v; want every permission granted", rr.Code, canReadSalary)
+	}
+}
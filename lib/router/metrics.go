@@ -0,0 +1,169 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/syedomair/backend-microservices/lib/metrics"
+	"go.uber.org/zap"
+)
+
+// unmatchedRoute labels requests outside every route, so unknown paths do not each become a series.
+// Unknown paths under /v1 are labelled with its pattern, /v1/*.
+const unmatchedRoute = "unmatched"
+
+// MetricsConfig configures the HTTP metrics of a router.
+type MetricsConfig struct {
+	// Namespace prefixes the metric names, such as "user_service" for user_service_http_requests_total.
+	Namespace string
+	// DurationBuckets are the buckets of the response time histogram, in seconds; nil means prometheus.DefBuckets.
+	DurationBuckets []float64
+	// SizeBuckets are the buckets of the request and response size histograms, in bytes; nil means 100B to 100MB.
+	SizeBuckets []float64
+	// Registry receives the metrics and is served on /metrics; nil means the default registry.
+	Registry *prometheus.Registry
+}
+
+// WithMetrics configures the HTTP metrics. Routers with the same namespace and registry share their
+// metrics, and the buckets of the first one apply.
+func WithMetrics(config MetricsConfig) Option {
+	return func(o *options) {
+		o.metrics = config
+	}
+}
+
+var defaultSizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+
+type httpMetrics struct {
+	requestsTotal *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	requestSize   *prometheus.HistogramVec
+	responseSize  *prometheus.HistogramVec
+	inFlight      prometheus.Gauge
+	rateLimited   *prometheus.CounterVec
+	handler       http.Handler
+}
+
+// newHTTPMetrics creates and registers the HTTP metrics. Metrics that fail to register still count
+// but are not exported; the error is logged.
+func newHTTPMetrics(logger *zap.Logger, config MetricsConfig) *httpMetrics {
+	durationBuckets := config.DurationBuckets
+	if durationBuckets == nil {
+		durationBuckets = prometheus.DefBuckets
+	}
+	sizeBuckets := config.SizeBuckets
+	if sizeBuckets == nil {
+		sizeBuckets = defaultSizeBuckets
+	}
+
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	m := &httpMetrics{handler: promhttp.Handler()}
+	if config.Registry != nil {
+		registerer = config.Registry
+		m.handler = promhttp.HandlerFor(config.Registry, promhttp.HandlerOpts{})
+	}
+
+	var errs []error
+	m.requestsTotal = register(registerer, prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_total",
+			Help:      "Total HTTP requests",
+		},
+		[]string{"method", "endpoint", "status"},
+	), &errs)
+	m.duration = register(registerer, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_response_time_seconds",
+			Help:      "HTTP response time distribution",
+			Buckets:   durationBuckets,
+		},
+		[]string{"method", "endpoint"},
+	), &errs)
+	m.requestSize = register(registerer, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_request_size_bytes",
+			Help:      "HTTP request body size distribution",
+			Buckets:   sizeBuckets,
+		},
+		[]string{"method", "endpoint"},
+	), &errs)
+	m.responseSize = register(registerer, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_response_size_bytes",
+			Help:      "HTTP response body size distribution",
+			Buckets:   sizeBuckets,
+		},
+		[]string{"method", "endpoint"},
+	), &errs)
+	m.inFlight = register(registerer, prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests being served",
+		},
+	), &errs)
+	m.rateLimited = register(registerer, prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "http_rate_limited_total",
+			Help:      "HTTP requests rejected by the rate limiter",
+		},
+		[]string{"endpoint"},
+	), &errs)
+
+	if err := errors.Join(errs...); err != nil {
+		logger.Error("failed to register HTTP metrics", zap.Error(err))
+	}
+	return m
+}
+
+// register registers collector, or returns the equivalent collector registered before, such as by
+// another router or test. Other failures are appended to errs and collector is returned unregistered.
+func register[T prometheus.Collector](registerer prometheus.Registerer, collector T, errs *[]error) T {
+	collector, err := metrics.Register(registerer, collector)
+	if err != nil {
+		*errs = append(*errs, err)
+	}
+	return collector
+}
+
+// middleware records the metrics of each request, labelled with the route pattern it matched.
+func (m *httpMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		endpoint := unmatchedRoute
+		if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+			endpoint = routeContext.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			// Nothing was written, which net/http answers with 200.
+			status = http.StatusOK
+		}
+		requestSize := r.ContentLength
+		if requestSize < 0 {
+			requestSize = 0
+		}
+
+		m.requestsTotal.WithLabelValues(r.Method, endpoint, strconv.Itoa(status)).Inc()
+		m.duration.WithLabelValues(r.Method, endpoint).Observe(time.Since(start).Seconds())
+		m.requestSize.WithLabelValues(r.Method, endpoint).Observe(float64(requestSize))
+		m.responseSize.WithLabelValues(r.Method, endpoint).Observe(float64(ww.BytesWritten()))
+	})
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestNewRouter_Metrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	routes := []EndPoint{
+		{Name: "GetUser", Method: Get, Pattern: "/users/{id}", HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello"))
+		}},
+		{Name: "Limited", Method: Post, Pattern: "/limited", RateLimit: &RateLimit{Rate: 0.001, Burst: 1},
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) }},
+	}
+	router := NewRouter(zap.NewNop(), routes, WithMetrics(MetricsConfig{Namespace: "test", Registry: registry}))
+
+	for _, path := range []string{"/v1/users/1", "/v1/users/2", "/v1/nowhere", "/nowhere"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Get, path, nil))
+	}
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Post, "/v1/limited", strings.NewReader("body")))
+	}
+
+	want := `
+# HELP test_http_requests_total Total HTTP requests
+# TYPE test_http_requests_total counter
+test_http_requests_total{endpoint="/v1/*",method="GET",status="404"} 1
+test_http_requests_total{endpoint="/v1/limited",method="POST",status="201"} 1
+test_http_requests_total{endpoint="/v1/limited",method="POST",status="429"} 1
+test_http_requests_total{endpoint="/v1/users/{id}",method="GET",status="200"} 2
+test_http_requests_total{endpoint="unmatched",method="GET",status="404"} 1
+# HELP test_http_rate_limited_total HTTP requests rejected by the rate limiter
+# TYPE test_http_rate_limited_total counter
+test_http_rate_limited_total{endpoint="Limited"} 1
+# HELP test_http_requests_in_flight HTTP requests being served
+# TYPE test_http_requests_in_flight gauge
+test_http_requests_in_flight 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"test_http_requests_total", "test_http_rate_limited_total", "test_http_requests_in_flight"); err != nil {
+		t.Error(err)
+	}
+
+	if got := testutil.CollectAndCount(registry, "test_http_response_size_bytes", "test_http_request_size_bytes", "test_http_response_time_seconds"); got != 12 {
+		t.Errorf("histogram series = %d, want 12", got)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(Get, "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `test_http_response_size_bytes_sum{endpoint="/v1/users/{id}",method="GET"} 10`) {
+		t.Errorf("/metrics does not serve the registry:\n%s", rr.Body.String())
+	}
+}
+
+func TestNewRouter_MetricsRegisteredTwice(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	routes := []EndPoint{
+		{Name: "Test", Method: Get, Pattern: "/test", HandlerFunc: func(w http.ResponseWriter, r *http.Request) {}},
+	}
+	config := MetricsConfig{Registry: registry, DurationBuckets: []float64{0.1, 1}}
+	first := NewRouter(zap.NewNop(), routes, WithMetrics(config))
+	second := NewRouter(zap.NewNop(), routes, WithMetrics(config))
+
+	first.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Get, "/v1/test", nil))
+	second.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Get, "/v1/test", nil))
+
+	want := `
+# HELP http_requests_total Total HTTP requests
+# TYPE http_requests_total counter
+http_requests_total{endpoint="/v1/test",method="GET",status="200"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "http_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	// The default registry is shared by every router of the tests in this package.
+	NewRouter(zap.NewNop(), routes)
+	NewRouter(zap.NewNop(), routes)
+}
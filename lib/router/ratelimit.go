@@ -0,0 +1,241 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/syedomair/backend-microservices/lib/response"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries the API key of clients that do not use bearer tokens.
+const APIKeyHeader = "X-API-Key"
+
+// MaxMemoryStoreBuckets caps the buckets a MemoryStore keeps between sweeps.
+const MaxMemoryStoreBuckets = 100_000
+
+const apiKeyIDKey contextKey = "apiKeyID"
+
+type apiKeySet map[[sha256.Size]byte]struct{}
+
+// WithAPIKeys lets clients that send one of keys in X-API-Key be rate limited by that key rather than
+// by IP address. Other X-API-Key values are ignored.
+func WithAPIKeys(keys ...string) Option {
+	return func(o *options) {
+		if o.apiKeys == nil {
+			o.apiKeys = apiKeySet{}
+		}
+		for _, key := range keys {
+			if key != "" {
+				o.apiKeys[sha256.Sum256([]byte(key))] = struct{}{}
+			}
+		}
+	}
+}
+
+// apiKeyMiddleware stores an ID of the request's API key in the context when it is one of keys.
+func apiKeyMiddleware(keys apiKeySet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+				sum := sha256.Sum256([]byte(apiKey))
+				if _, ok := keys[sum]; ok {
+					r = r.WithContext(context.WithValue(r.Context(), apiKeyIDKey, hex.EncodeToString(sum[:8])))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit is a token bucket that refills Rate tokens per second up to Burst. Each request takes one token.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+	// Key identifies the client; nil means KeyByClient.
+	Key KeyFunc
+}
+
+func (l RateLimit) valid() bool {
+	return l.Rate > 0 && l.Burst >= 1
+}
+
+// KeyFunc returns the rate limiting key of a request's client.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP keys clients by IP address.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// KeyByClient keys clients by JWT subject, then by an API key accepted WithAPIKeys, then by IP address.
+// Unknown API keys fall back to the IP address, so a client cannot get a fresh bucket by making one up.
+func KeyByClient(r *http.Request) string {
+	if claims, ok := ClaimsFromContext(r.Context()); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject
+	}
+	if keyID, ok := r.Context().Value(apiKeyIDKey).(string); ok {
+		return "key:" + keyID
+	}
+	return KeyByIP(r)
+}
+
+// RateLimitResult is the state of a bucket after a request tried to take a token.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available; zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// RateLimitStore keeps the token buckets. MemoryStore keeps them in process; a shared store lets
+// several replicas enforce one limit.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// WithDefaultRateLimit limits every /v1 endpoint that has no RateLimit of its own. Each endpoint
+// still gets its own buckets.
+func WithDefaultRateLimit(limit RateLimit) Option {
+	return func(o *options) {
+		o.defaultRateLimit = &limit
+	}
+}
+
+// WithRateLimitStore replaces the in-memory store used by EndPoint rate limits.
+func WithRateLimitStore(store RateLimitStore) Option {
+	return func(o *options) {
+		o.rateLimitStore = store
+	}
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time
+}
+
+// MemoryStore is a RateLimitStore local to the process. Buckets that have refilled are dropped, and
+// once MaxMemoryStoreBuckets are kept an arbitrary one is dropped for each new client.
+type MemoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*bucket
+	maxBuckets int
+	now        func() time.Time
+	lastSweep  time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, maxBuckets: MaxMemoryStoreBuckets, now: time.Now}
+}
+
+const sweepInterval = time.Minute
+
+// Take implements RateLimitStore.
+func (s *MemoryStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	burst := float64(limit.Burst)
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= s.maxBuckets {
+			s.dropOne()
+		}
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((burst - b.tokens) / limit.Rate)
+	b.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep drops the buckets that have refilled, since a new bucket starts full anyway.
+func (s *MemoryStore) sweep(now time.Time) {
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// dropOne drops an arbitrary bucket to make room for a new one.
+func (s *MemoryStore) dropOne() {
+	for key := range s.buckets {
+		delete(s.buckets, key)
+		return
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// rateLimitMiddleware takes a token per request from the client's bucket for the endpoint and rejects
+// requests that find it empty with 429, counting them in rateLimited. Requests are let through when the store fails.
+func rateLimitMiddleware(logger *zap.Logger, store RateLimitStore, rateLimited *prometheus.CounterVec, endpoint string, limit RateLimit) func(next http.Handler) http.Handler {
+	keyFunc := limit.Key
+	if keyFunc == nil {
+		keyFunc = KeyByClient
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methodName := "rateLimitMiddleware"
+
+			result, err := store.Take(r.Context(), endpoint+"|"+keyFunc(r), limit)
+			if err != nil {
+				logger.Error("rate limit store failed", zap.String("endpoint", endpoint), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			if !result.Allowed {
+				rateLimited.WithLabelValues(endpoint).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				response.ErrorResponseHelper(methodName, w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
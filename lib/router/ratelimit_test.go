@@ -0,0 +1,197 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"go.uber.org/zap"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := RateLimit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, _ := store.Take(ctx, "client", limit)
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("request %d: result = %+v, want allowed with %d remaining", 3-i, result, i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "client", limit)
+	if result.Allowed || result.RetryAfter != 500*time.Millisecond || result.Reset != 1500*time.Millisecond {
+		t.Fatalf("empty bucket: result = %+v", result)
+	}
+	if other, _ := store.Take(ctx, "other", limit); !other.Allowed {
+		t.Fatalf("other client should have its own bucket")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if result, _ := store.Take(ctx, "client", limit); !result.Allowed {
+		t.Fatalf("refilled bucket: result = %+v", result)
+	}
+
+	now = now.Add(time.Hour)
+	store.Take(ctx, "client", limit)
+	if _, ok := store.buckets["other"]; ok {
+		t.Errorf("expected the refilled bucket of an idle client to be swept")
+	}
+}
+
+func TestMemoryStore_MaxBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxBuckets = 2
+	limit := RateLimit{Rate: 1, Burst: 1}
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if result, _ := store.Take(ctx, key, limit); !result.Allowed {
+			t.Fatalf("first request of %s: result = %+v, want allowed", key, result)
+		}
+	}
+	if len(store.buckets) != 2 {
+		t.Errorf("kept %d buckets, want at most 2", len(store.buckets))
+	}
+	if _, ok := store.buckets["c"]; !ok {
+		t.Errorf("expected the newest client to get a bucket")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, RateLimit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store down")
+}
+
+func TestNewRouter_RateLimit(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	routes := []EndPoint{
+		{Name: "Limited", Method: Get, Pattern: "/limited", HandlerFunc: handler, RateLimit: &RateLimit{Rate: 0.001, Burst: 2}},
+		{Name: "Unlimited", Method: Get, Pattern: "/unlimited", HandlerFunc: handler},
+	}
+	router := NewRouter(zap.NewNop(), routes)
+
+	call := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(Get, path, nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rr := call("/v1/limited", "10.0.0.1:1234")
+		if rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Remaining") != wantRemaining || rr.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: status = %d, headers = %v", i+1, rr.Code, rr.Header())
+		}
+	}
+
+	rr := call("/v1/limited", "10.0.0.1:5678")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") != "1000" || rr.Header().Get("X-RateLimit-Reset") != "2000" {
+		t.Errorf("headers = %v", rr.Header())
+	}
+
+	if rr := call("/v1/limited", "10.0.0.2:1234"); rr.Code != http.StatusOK {
+		t.Errorf("another IP: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if rr := call("/v1/unlimited", "10.0.0.1:1234"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("unlimited endpoint: status = %d, headers = %v", rr.Code, rr.Header())
+	}
+}
+
+func TestNewRouter_RateLimitStoreFailure(t *testing.T) {
+	routes := []EndPoint{
+		{Name: "Limited", Method: Get, Pattern: "/limited", RateLimit: &RateLimit{Rate: 1, Burst: 1},
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }},
+	}
+	router := NewRouter(zap.NewNop(), routes, WithRateLimitStore(failingStore{}))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(Get, "/v1/limited", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestNewRouter_DefaultRateLimit(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	routes := []EndPoint{
+		{Name: "Default", Method: Get, Pattern: "/default", HandlerFunc: handler},
+		{Name: "Override", Method: Get, Pattern: "/override", HandlerFunc: handler, RateLimit: &RateLimit{Rate: 0.001, Burst: 3}},
+	}
+	router := NewRouter(zap.NewNop(), routes, WithDefaultRateLimit(RateLimit{Rate: 0.001, Burst: 1}))
+
+	call := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(Get, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := call("/v1/default"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("default limit: status = %d, headers = %v", rr.Code, rr.Header())
+	}
+	if rr := call("/v1/default"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("default limit: status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	for i := 0; i < 3; i++ {
+		if rr := call("/v1/override"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Limit") != "3" {
+			t.Fatalf("override request %d: status = %d, headers = %v", i+1, rr.Code, rr.Header())
+		}
+	}
+}
+
+func TestKeyByClient(t *testing.T) {
+	o := options{}
+	WithAPIKeys("secret")(&o)
+	keyOf := func(r *http.Request) string {
+		var key string
+		apiKeyMiddleware(o.apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = KeyByClient(r)
+		})).ServeHTTP(httptest.NewRecorder(), r)
+		return key
+	}
+
+	withClaims := httptest.NewRequest(Get, "/", nil)
+	withClaims.Header.Set(APIKeyHeader, "secret")
+	withClaims = withClaims.WithContext(context.WithValue(withClaims.Context(), ClaimsKey, &auth.Claims{Subject: "user-1"}))
+
+	withAPIKey := httptest.NewRequest(Get, "/", nil)
+	withAPIKey.Header.Set(APIKeyHeader, "secret")
+
+	withUnknownKey := httptest.NewRequest(Get, "/", nil)
+	withUnknownKey.RemoteAddr = "10.0.0.1:1234"
+	withUnknownKey.Header.Set(APIKeyHeader, "made-up")
+
+	anonymous := httptest.NewRequest(Get, "/", nil)
+	anonymous.RemoteAddr = "10.0.0.1:1234"
+
+	if got := keyOf(withClaims); got != "sub:user-1" {
+		t.Errorf("KeyByClient(claims) = %q", got)
+	}
+	if got := keyOf(withAPIKey); got == "" || got[:4] != "key:" || got == "key:secret" {
+		t.Errorf("KeyByClient(api key) = %q, want a hashed key", got)
+	}
+	if got := keyOf(withUnknownKey); got != "ip:10.0.0.1" {
+		t.Errorf("KeyByClient(unknown api key) = %q, want the IP address", got)
+	}
+	if got := keyOf(anonymous); got != "ip:10.0.0.1" {
+		t.Errorf("KeyByClient(anonymous) = %q", got)
+	}
+	if got := KeyByClient(withAPIKey); got[:3] != "ip:" {
+		t.Errorf("KeyByClient(api key) without WithAPIKeys = %q, want the IP address", got)
+	}
+}
@@ -7,9 +7,8 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
-	"github.com/google/uuid"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/logging"
 	"go.uber.org/zap"
 )
 
@@ -26,53 +25,75 @@ type EndPoint struct {
 	Method      string
 	Pattern     string
 	HandlerFunc http.HandlerFunc
+	// Public routes skip authentication when the router has an Authenticator.
+	Public bool
+	// Permissions the caller's roles must grant, such as auth.PermUsersRead. Ignored on Public routes.
+	Permissions []string
+	// RateLimit, when set, limits how often each client may call the endpoint, overriding WithDefaultRateLimit.
+	RateLimit *RateLimit
 }
 type contextKey string
 
-const (
-	RequestIDKey contextKey = "requestID"
-)
+// RequestIDKey holds the request ID in the request context.
+//
+// Deprecated: use logging.RequestID, which also works in gRPC servers. The key is still populated.
+const RequestIDKey contextKey = "requestID"
 
-var (
-	httpRequestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total HTTP requests",
-		},
-		[]string{"method", "endpoint", "status"},
-	)
-
-	httpDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "http_response_time_seconds",
-			Help: "HTTP response time distribution",
-		},
-		[]string{"endpoint"},
-	)
-)
+func NewRouter(logger *zap.Logger, routes []EndPoint, opts ...Option) *chi.Mux {
+	o := options{rolePermissions: auth.DefaultRolePermissions, rateLimitStore: NewMemoryStore()}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-func init() {
-	prometheus.MustRegister(httpRequestsTotal, httpDuration)
-}
-func NewRouter(logger *zap.Logger, routes []EndPoint) *chi.Mux {
 	router := chi.NewRouter()
 
 	// Common middleware
 	router.Use(
-		middleware.RequestID,
 		middleware.RealIP,
 		middleware.Recoverer,
 		middleware.Timeout(60*time.Second),
 	)
 
 	// Custom middleware
+	metrics := newHTTPMetrics(logger, o.metrics)
+	router.Use(tracingMiddleware(o.tracerProvider))
 	router.Use(loggingMiddleware(logger))
-	router.Use(prometheusMiddleware)
+	router.Use(metrics.middleware)
+	if len(o.apiKeys) > 0 {
+		router.Use(apiKeyMiddleware(o.apiKeys))
+	}
 
 	// Routes
 	router.Route("/v1", func(r chi.Router) {
+		var protected chi.Router
+		if o.authenticator != nil {
+			protected = r.With(authMiddleware(logger, o.authenticator, o.rolePermissions))
+		} else {
+			logger.Warn("no authenticator configured, /v1 routes are unauthenticated")
+			protected = r.With(grantAllMiddleware)
+		}
 		for _, route := range routes {
-			r.Method(route.Method, route.Pattern, route.HandlerFunc)
+			handler := http.Handler(route.HandlerFunc)
+			if len(route.Permissions) > 0 && !route.Public {
+				handler = permissionMiddleware(logger, route.Permissions)(handler)
+			}
+			limit := route.RateLimit
+			if limit == nil {
+				limit = o.defaultRateLimit
+			}
+			if limit != nil {
+				if limit.valid() {
+					handler = rateLimitMiddleware(logger, o.rateLimitStore, metrics.rateLimited, route.Name, *limit)(handler)
+				} else {
+					logger.Error("invalid rate limit, endpoint is not limited", zap.String("endpoint", route.Name),
+						zap.Float64("rate", limit.Rate), zap.Int("burst", limit.Burst))
+				}
+			}
+			if route.Public {
+				r.Method(route.Method, route.Pattern, handler)
+				continue
+			}
+			protected.Method(route.Method, route.Pattern, handler)
 		}
 	})
 
@@ -82,28 +103,38 @@ func NewRouter(logger *zap.Logger, routes []EndPoint) *chi.Mux {
 	})
 
 	// Prometheus metrics endpoint
-	router.Handle("/metrics", promhttp.Handler())
+	router.Handle("/metrics", metrics.handler)
 
 	return router
 }
+
+// loggingMiddleware logs each request with its request ID: the client's X-Request-ID when it is
+// valid and a new one otherwise. The ID is echoed in the response, and handlers log with it through
+// logging.FromContext.
 func loggingMiddleware(logger *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			requestID := uuid.New().String()
-			ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
-
-			logger.Info("request started",
+			requestID := r.Header.Get(logging.RequestIDHeader)
+			if !logging.ValidRequestID(requestID) {
+				requestID = logging.NewRequestID()
+			}
+			w.Header().Set(logging.RequestIDHeader, requestID)
+			ctx := logging.NewContext(r.Context(), logger, requestID)
+			// Keep the request ID where RequestIDKey and chi's middleware.GetReqID read it.
+			ctx = context.WithValue(ctx, RequestIDKey, requestID)
+			ctx = context.WithValue(ctx, middleware.RequestIDKey, requestID)
+			requestLogger := logging.FromContext(ctx, logger)
+
+			requestLogger.Info("request started",
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
-				zap.String("request_id", requestID),
 			)
 
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			next.ServeHTTP(ww, r.WithContext(ctx))
 
-			logger.Info("request completed",
-				zap.String("request_id", requestID),
+			requestLogger.Info("request completed",
 				zap.Int("status", ww.Status()),
 				zap.Int("response_size", ww.BytesWritten()),
 				zap.String("client_ip", r.RemoteAddr),
@@ -112,17 +143,3 @@ func loggingMiddleware(logger *zap.Logger) func(next http.Handler) http.Handler
 		})
 	}
 }
-
-func prometheusMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-		next.ServeHTTP(ww, r)
-
-		duration := time.Since(start)
-		endpoint := r.URL.Path
-
-		httpRequestsTotal.WithLabelValues(r.Method, endpoint, string(rune(ww.Status()))).Inc()
-		httpDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
-	})
-}
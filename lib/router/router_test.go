@@ -5,7 +5,10 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/middleware"
+	"github.com/syedomair/backend-microservices/lib/logging"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestNewRouter(t *testing.T) {
@@ -43,46 +46,53 @@ func TestLoggingMiddleware(t *testing.T) {
 }
 
 func TestLoggingMiddleware_RequestID(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
+	core, logs := observer.New(zap.DebugLevel)
+	var handlerRequestID string
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerRequestID = logging.RequestID(r.Context())
+		if r.Context().Value(RequestIDKey) != handlerRequestID || middleware.GetReqID(r.Context()) != handlerRequestID {
+			t.Errorf("RequestIDKey = %v, middleware.GetReqID = %q, want %q", r.Context().Value(RequestIDKey), middleware.GetReqID(r.Context()), handlerRequestID)
+		}
+		logging.FromContext(r.Context(), zap.NewNop()).Info("handler")
 		w.WriteHeader(http.StatusOK)
 	})
-	middlewareHandler := loggingMiddleware(logger)(nextHandler)
-	req, err := http.NewRequest(Get, "/test", nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	rr := httptest.NewRecorder()
-	handler := middlewareHandler
-	handler.ServeHTTP(rr, req)
-	if rr.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	middlewareHandler := loggingMiddleware(zap.New(core))(nextHandler)
+
+	tests := []struct {
+		name   string
+		header string
+		wantID string
+	}{
+		{name: "Honors the client ID", header: "client-id-1", wantID: "client-id-1"},
+		{name: "Replaces an invalid ID", header: "bad id\n"},
+		{name: "Generates an ID", header: ""},
 	}
-	// Check that the request ID is logged
-	// This requires a way to inspect the logs, which can be done using a test logger
-	// that captures the logs and allows them to be inspected.
-}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+			req, err := http.NewRequest(Get, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			middlewareHandler.ServeHTTP(rr, req)
 
-func TestLoggingMiddleware_Context(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		/*
-			requestID, ok := r.Context().Value(RequestIDKey).(string)
-			if !ok {
-				t.Errorf("Expected request ID to be in context, got none")
+			requestID := rr.Header().Get(logging.RequestIDHeader)
+			if requestID == "" || requestID != handlerRequestID {
+				t.Fatalf("response ID = %q, handler ID = %q, want the same ID", requestID, handlerRequestID)
 			}
-		*/
-		w.WriteHeader(http.StatusOK)
-	})
-	middlewareHandler := loggingMiddleware(logger)(nextHandler)
-	req, err := http.NewRequest(Get, "/test", nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	rr := httptest.NewRecorder()
-	handler := middlewareHandler
-	handler.ServeHTTP(rr, req)
-	if rr.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+			if tt.wantID != "" && requestID != tt.wantID {
+				t.Errorf("request ID = %q, want %q", requestID, tt.wantID)
+			}
+			if requestID == tt.header && tt.wantID == "" {
+				t.Errorf("request ID = %q, want a new one", requestID)
+			}
+			if entries := logs.FilterField(zap.String("request_id", requestID)).All(); len(entries) != 3 {
+				t.Errorf("logged %d entries with the request ID, want start, handler and end", len(entries))
+			}
+		})
 	}
 }
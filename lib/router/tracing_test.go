@@ -0,0 +1,60 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestNewRouter_Tracing(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	var handlerSpan trace.SpanContext
+	routes := []EndPoint{
+		{Name: "GetUser", Method: Get, Pattern: "/users/{id}", HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			handlerSpan = trace.SpanContextFromContext(r.Context())
+		}},
+		{Name: "Fail", Method: Get, Pattern: "/fail", HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}},
+	}
+	router := NewRouter(zap.NewNop(), routes, WithTracerProvider(provider))
+
+	req := httptest.NewRequest(Get, "/v1/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Get, "/v1/fail", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Get, "/health", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want one per request except the health check", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "GET /v1/users/{id}" || span.SpanKind != trace.SpanKindServer {
+		t.Errorf("span = %q (%v), want server span GET /v1/users/{id}", span.Name, span.SpanKind)
+	}
+	if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("span does not continue the traceparent: trace %s, parent %s", span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext.SpanID() {
+		t.Errorf("handler context span = %s, want %s", handlerSpan.SpanID(), span.SpanContext.SpanID())
+	}
+
+	if spans[1].Name != "GET /v1/fail" || spans[1].Status.Code != codes.Error {
+		t.Errorf("failed request span = %q with status %v, want an error", spans[1].Name, spans[1].Status)
+	}
+}
@@ -0,0 +1,143 @@
+// Package search builds ranked text search conditions for the database dialects the services run on.
+//
+// On Postgres rows match on full-text search (the 'simple' configuration, so names are not stemmed),
+// on a case-insensitive substring and, when the pg_trgm extension is installed, on trigram word
+// similarity, which tolerates typos. Other dialects match on a case-insensitive substring only.
+// Exact matches rank above prefix matches, which rank above other substring matches; Postgres
+// breaks ties by text rank and similarity.
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Query is a search condition and the expression of each matching row's relevance, higher first.
+type Query struct {
+	Where    string
+	Args     []interface{}
+	Rank     string
+	RankArgs []interface{}
+}
+
+// trigramRetryInterval is how long a Searcher waits before checking for pg_trgm again after a check failed.
+const trigramRetryInterval = time.Minute
+
+// Searcher builds Queries for one database.
+type Searcher struct {
+	db *gorm.DB
+
+	mu      sync.Mutex
+	checked bool
+	trigram bool
+	retryAt time.Time
+}
+
+// New returns a Searcher for db. Whether pg_trgm is installed is checked on first use.
+func New(db *gorm.DB) *Searcher {
+	return &Searcher{db: db}
+}
+
+// Query returns the search for term on columns, which must be trusted column names. The
+// matching Postgres indexes are created by the 0003_search migration. ctx bounds the check for pg_trgm.
+func (s *Searcher) Query(ctx context.Context, term string, columns ...string) Query {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if dialect := s.db.Dialector.Name(); dialect != "postgres" {
+		greatest := "GREATEST"
+		if dialect == "sqlite" {
+			greatest = "MAX"
+		}
+		return likeQuery(term, columns, "LOWER(%s) LIKE ? ESCAPE '!'", greatest)
+	}
+
+	q := likeQuery(term, columns, "%s ILIKE ? ESCAPE '!'", "GREATEST")
+	document := tsvector(columns)
+	q.Where = document + " @@ plainto_tsquery('simple', ?) OR " + q.Where
+	q.Args = append([]interface{}{term}, q.Args...)
+	q.Rank += " + ts_rank(" + document + ", plainto_tsquery('simple', ?))"
+	q.RankArgs = append(q.RankArgs, term)
+
+	if s.hasTrigram(ctx) {
+		similarities := make([]string, len(columns))
+		for i, column := range columns {
+			q.Where += " OR ? <% " + column
+			q.Args = append(q.Args, term)
+			similarities[i] = "COALESCE(word_similarity(?, " + column + "), 0)"
+			q.RankArgs = append(q.RankArgs, term)
+		}
+		q.Rank += " + GREATEST(" + strings.Join(similarities, ", ") + ")"
+	}
+	q.Where = "(" + q.Where + ")"
+	return q
+}
+
+// likeQuery matches term as a substring of any column, with match formatting one comparison and
+// greatest naming the dialect's multi-argument maximum.
+func likeQuery(term string, columns []string, match, greatest string) Query {
+	escaped := escapeLike(term)
+	contains, prefix := "%"+escaped+"%", escaped+"%"
+
+	q := Query{}
+	conditions := make([]string, len(columns))
+	ranks := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = strings.ReplaceAll(match, "%s", column)
+		q.Args = append(q.Args, contains)
+
+		ranks[i] = "CASE WHEN LOWER(" + column + ") = ? THEN 3 WHEN " + conditions[i] + " THEN 2 WHEN " + conditions[i] + " THEN 1 ELSE 0 END"
+		q.RankArgs = append(q.RankArgs, term, prefix, contains)
+	}
+	q.Where = strings.Join(conditions, " OR ")
+	q.Rank = greatest + "(" + strings.Join(ranks, ", ") + ")"
+	if len(ranks) == 1 {
+		q.Rank = ranks[0]
+	}
+	return q
+}
+
+// tsvector is the document searched on Postgres; it must match the expression of the search indexes.
+func tsvector(columns []string) string {
+	coalesced := make([]string, len(columns))
+	for i, column := range columns {
+		coalesced[i] = "coalesce(" + column + ", '')"
+	}
+	return "to_tsvector('simple', " + strings.Join(coalesced, " || ' ' || ") + ")"
+}
+
+// hasTrigram reports whether pg_trgm is installed. The check runs without holding s.mu, so a slow
+// database only delays the searches that wait for it. A failed check is retried after
+// trigramRetryInterval, or on the next search when it failed because ctx was done.
+func (s *Searcher) hasTrigram(ctx context.Context) bool {
+	s.mu.Lock()
+	checked, trigram, retryAt := s.checked, s.trigram, s.retryAt
+	s.mu.Unlock()
+	if checked {
+		return trigram
+	}
+	if time.Now().Before(retryAt) {
+		return false
+	}
+
+	var installed bool
+	err := s.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&installed).Error
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if ctx.Err() == nil {
+			s.retryAt = time.Now().Add(trigramRetryInterval)
+		}
+		return false
+	}
+	s.trigram, s.checked = installed, true
+	return installed
+}
+
+// escapeLike escapes the LIKE wildcards with '!', which unlike '\' needs no escaping in any dialect's strings.
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
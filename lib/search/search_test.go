@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/syedomair/backend-microservices/lib/testdb"
+)
+
+func TestSearcher_Query(t *testing.T) {
+	db := testdb.New(t)
+	searcher := New(db)
+
+	tests := []struct {
+		name      string
+		term      string
+		wantNames []string
+	}{
+		{name: "Ignores case and spaces", term: "  HANNAH baker ", wantNames: []string{"Hannah Baker"}},
+		{name: "Prefix ranks first", term: "ian", wantNames: []string{"Ian Malcolm", "Diana Prince"}},
+		{name: "Matches email", term: "costanza@", wantNames: []string{"George Costanza"}},
+		{name: "Wildcards are literal", term: "%", wantNames: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := searcher.Query(context.Background(), tt.term, "name", "email")
+			rows := []struct{ Name string }{}
+			err := db.Table("user").
+				Select("name, "+query.Rank+" AS search_rank", query.RankArgs...).
+				Where(query.Where, query.Args...).
+				Order("search_rank DESC, name").
+				Scan(&rows).Error
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			names := []string{}
+			for _, row := range rows {
+				names = append(names, row.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("names = %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("names = %v, want %v", names, tt.wantNames)
+				}
+			}
+		})
+	}
+}
+
+func TestTsvector(t *testing.T) {
+	want := "to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(email, ''))"
+	if got := tsvector([]string{"name", "email"}); got != want {
+		t.Errorf("tsvector() = %q, want %q", got, want)
+	}
+}
+
+func TestSearcher_hasTrigram(t *testing.T) {
+	// SQLite has no pg_extension table, so every check fails.
+	searcher := New(testdb.New(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if searcher.hasTrigram(ctx) {
+		t.Fatal("hasTrigram() = true for a failed check")
+	}
+	if !searcher.retryAt.IsZero() {
+		t.Errorf("retryAt = %v, want a check canceled by the caller to be retried on the next search", searcher.retryAt)
+	}
+
+	if searcher.hasTrigram(context.Background()) {
+		t.Fatal("hasTrigram() = true for a failed check")
+	}
+	if searcher.checked || time.Until(searcher.retryAt) <= 0 {
+		t.Errorf("checked = %v, retryAt = %v, want a failed check retried later", searcher.checked, searcher.retryAt)
+	}
+}
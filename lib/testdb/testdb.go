@@ -0,0 +1,54 @@
+// Package testdb provides repository tests with a seeded in-memory SQLite database,
+// so they can run without Docker or a database server.
+package testdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/migrate"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var counter atomic.Int64
+
+// New returns a private in-memory database migrated to the latest schema and loaded with database/sqlite/data_script.sql.
+// The database is closed when the test finishes.
+func New(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	root := repoRoot()
+	// A unique name keeps databases of parallel tests apart.
+	url := fmt.Sprintf("file:testdb%d?mode=memory", counter.Add(1))
+	db, err := container.NewSQLiteAdapter(url, filepath.Join(root, "config", "gorm-logger-config.json")).MakeConnection()
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := migrate.New(db, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate schema: %v", err)
+	}
+	if err := container.RunSQLFile(db, filepath.Join(root, "database", "sqlite", "data_script.sql")); err != nil {
+		t.Fatalf("failed to load seed data: %v", err)
+	}
+	return db
+}
+
+func repoRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
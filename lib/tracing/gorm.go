@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const instrumentationName = "github.com/syedomair/backend-microservices/lib/tracing"
+
+const spanKey = "tracing:span"
+
+// Plugin is a gorm plugin that records a client span for every statement. Statements run on a
+// db.WithContext(ctx) session become children of the span in ctx.
+type Plugin struct {
+	// TracerProvider creates the spans; nil means the global one.
+	TracerProvider trace.TracerProvider
+}
+
+var _ gorm.Plugin = (*Plugin)(nil)
+
+func (p *Plugin) Name() string {
+	return "tracing"
+}
+
+// Initialize registers the callbacks around gorm's create, query, update, delete, row and raw statements.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	provider := p.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(instrumentationName)
+	system := dbSystem(db.Dialector.Name())
+
+	callbacks := db.Callback()
+	var errs []error
+	register := func(operation string, before, after func(string, func(*gorm.DB)) error) {
+		errs = append(errs,
+			before("tracing:before_"+operation, p.before(tracer, system, operation)),
+			after("tracing:after_"+operation, p.after),
+		)
+	}
+	register("create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register)
+	register("query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register)
+	register("update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register)
+	register("delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register)
+	register("row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register)
+	register("raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register)
+	return errors.Join(errs...)
+}
+
+// before starts the span of a statement and passes it on to the driver in the statement's context.
+func (p *Plugin) before(tracer trace.Tracer, system attribute.KeyValue, operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		attributes := []attribute.KeyValue{system, semconv.DBOperationName(operation)}
+		if db.Statement.Table != "" {
+			attributes = append(attributes, semconv.DBCollectionName(db.Statement.Table))
+		}
+		ctx, span := tracer.Start(db.Statement.Context, spanName(operation, db.Statement.Table),
+			trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// after ends the span started by before with the statement's SQL, which holds placeholders and
+// not the values, and its outcome.
+func (p *Plugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+func spanName(operation, table string) string {
+	if table == "" {
+		return "gorm." + operation
+	}
+	return "gorm." + operation + " " + table
+}
+
+func dbSystem(dialector string) attribute.KeyValue {
+	switch strings.ToLower(dialector) {
+	case "postgres":
+		return semconv.DBSystemPostgreSQL
+	case "mysql":
+		return semconv.DBSystemMySQL
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	}
+	return semconv.DBSystemKey.String(dialector)
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func TestPlugin(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(&Plugin{TracerProvider: provider}); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if err := db.WithContext(ctx).Create(&item{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var items []item
+	if err := db.WithContext(ctx).Where("name = ?", "a").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(ctx).Exec("SELECT * FROM missing").Error; err == nil {
+		t.Fatal("query on a missing table succeeded")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want 3 statements and the parent", len(spans))
+	}
+	wantNames := []string{"gorm.create items", "gorm.query items", "gorm.raw"}
+	for i, want := range wantNames {
+		span := spans[i]
+		if span.Name != want {
+			t.Errorf("span %d name = %q, want %q", i, span.Name, want)
+		}
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the request span", span.Name)
+		}
+		if !hasAttribute(span.Attributes, "db.system", "sqlite") {
+			t.Errorf("span %q attributes = %v, want db.system sqlite", span.Name, span.Attributes)
+		}
+	}
+	if !hasAttribute(spans[1].Attributes, "db.query.text", "SELECT * FROM `items` WHERE name = ?") {
+		t.Errorf("query span attributes = %v, want the SQL without values", spans[1].Attributes)
+	}
+	if spans[2].Status.Code != codes.Error {
+		t.Errorf("failed statement status = %v, want an error", spans[2].Status)
+	}
+}
+
+func hasAttribute(attributes []attribute.KeyValue, key, value string) bool {
+	for _, kv := range attributes {
+		if string(kv.Key) == key && kv.Value.Emit() == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Package tracing sets up OpenTelemetry tracing for the services.
+//
+// Setup installs the global tracer provider and the W3C trace context propagator, which the router
+// middleware, the gRPC stats handlers and the GORM Plugin use, so one trace follows a request from
+// the HTTP API through point_service and into the database.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters.
+const (
+	// None records no spans but still propagates incoming trace context.
+	None = "none"
+	// Stdout writes spans to standard output as JSON.
+	Stdout = "stdout"
+	// File appends spans to Config.File as JSON, one span per line.
+	File = "file"
+	// OTLP sends spans over gRPC to Config.Endpoint, or to the OTEL_EXPORTER_OTLP_* endpoint when empty.
+	OTLP = "otlp"
+)
+
+// Config configures Setup.
+type Config struct {
+	// ServiceName is the service.name resource attribute, such as "user_service".
+	ServiceName string
+	// Exporter is one of None, Stdout, File or OTLP; empty means None.
+	Exporter string
+	// File is the path the File exporter appends to.
+	File string
+	// Endpoint is the URL of the OTLP collector, such as "http://otel-collector:4317"; http means no TLS.
+	Endpoint string
+	// SampleRatio is the fraction of new traces recorded. Requests that arrive with a sampled
+	// parent are always recorded, so a trace is never cut short halfway.
+	SampleRatio float64
+}
+
+// ShutdownFunc flushes the spans not exported yet and releases the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the tracer provider and propagator described by config as the otel globals.
+func Setup(ctx context.Context, config Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v: must be between 0 and 1", config.SampleRatio)
+	}
+
+	var exporter sdktrace.SpanExporter
+	var closer io.Closer
+	var err error
+	switch config.Exporter {
+	case "", None:
+		return func(context.Context) error { return nil }, nil
+	case Stdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case File:
+		if config.File == "" {
+			return nil, errors.New("the file exporter needs a file")
+		}
+		var file *os.File
+		file, err = os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %v", err)
+		}
+		closer = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case OTLP:
+		var opts []otlptracegrpc.Option
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(config.Endpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("invalid exporter %q: must be %s, %s, %s or %s", config.Exporter, None, Stdout, File, OTLP)
+	}
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("failed to create trace exporter: %v", err)
+	}
+
+	attributes := resource.Default()
+	if config.ServiceName != "" {
+		attributes, err = resource.Merge(attributes, resource.NewSchemaless(semconv.ServiceName(config.ServiceName)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(attributes),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
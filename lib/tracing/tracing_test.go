@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup_File(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), Config{ServiceName: "test_service", Exporter: File, File: path, SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Name":"work"`) || !strings.Contains(string(data), "test_service") {
+		t.Errorf("trace file = %s, want the span and the service name", data)
+	}
+
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	out := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, out)
+	if out["traceparent"] != carrier["traceparent"] {
+		t.Errorf("propagated traceparent = %q, want %q", out["traceparent"], carrier["traceparent"])
+	}
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "Unknown exporter", config: Config{Exporter: "zipkin", SampleRatio: 1}},
+		{name: "File without path", config: Config{Exporter: File, SampleRatio: 1}},
+		{name: "Sample ratio above 1", config: Config{Exporter: Stdout, SampleRatio: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Setup(context.Background(), tt.config); err == nil {
+				t.Errorf("Setup() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -12,7 +12,16 @@ func (Department) TableName() string {
 	return "department"
 }
 
+// DepartmentInput is the request body accepted when creating or patching a department.
+// Nil fields are left untouched on PATCH.
+type DepartmentInput struct {
+	Name    *string `json:"name"`
+	Address *string `json:"address"`
+}
+
 type ResponseDepartment struct {
-	Count string      `json:"count" `
-	List  interface{} `json:"list" `
+	Count      string      `json:"count" `
+	List       interface{} `json:"list" `
+	NextCursor string      `json:"next_cursor,omitempty" mapstructure:"NextCursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty" mapstructure:"PrevCursor,omitempty"`
 }
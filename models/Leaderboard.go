@@ -0,0 +1,18 @@
+package models
+
+// LeaderboardEntry Public
+// Rank uses competition ranking: users with equal points share a rank and the
+// next rank skips accordingly (1, 2, 2, 4).
+type LeaderboardEntry struct {
+	Rank   int    `json:"rank"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name,omitempty"`
+	Points int    `json:"points"`
+}
+
+// ResponseLeaderboard Public
+type ResponseLeaderboard struct {
+	DepartmentID string              `json:"department_id,omitempty"`
+	Limit        int                 `json:"limit"`
+	List         []*LeaderboardEntry `json:"list"`
+}
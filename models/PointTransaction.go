@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PointTransaction Public
+// One row per change to a user's balance. Rows are only ever inserted; the
+// points table keeps the resulting balance as a snapshot for fast reads.
+type PointTransaction struct {
+	ID           string    `json:"id" gorm:"column:id"`
+	UserID       string    `json:"user_id" gorm:"column:user_id"`
+	Delta        int       `json:"delta" gorm:"column:delta"`
+	BalanceAfter int       `json:"balance_after" gorm:"column:balance_after"`
+	Reason       string    `json:"reason" gorm:"column:reason"`
+	Actor        string    `json:"actor" gorm:"column:actor"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// TableName Public
+func (PointTransaction) TableName() string {
+	return "point_transactions"
+}
@@ -5,35 +5,147 @@ type User struct {
 	ID           string  `json:"id" gorm:"column:id"`
 	Name         string  `json:"name" gorm:"column:name"`
 	Email        string  `json:"email" gorm:"column:email"`
-	DepartmentID string  `json:"department_id" gorm:"column:department_id"`
+	DepartmentID *string `json:"department_id" gorm:"column:department_id"`
 	Age          int     `json:"age" gorm:"column:age"`
-	Salary       float32 `json:"salary" gorm:"column:salary"`
-	Point        int     `json:"point" gorm:"column:point"`
+	Salary       float64 `json:"salary" gorm:"column:salary"`
+	Point        *int    `json:"point" gorm:"-"` // nil when points are unavailable
 }
 
 // TableName Public
 func (User) TableName() string {
-	return "public.user"
+	return "user"
+}
+
+// RedactedUser serializes a User without its salary, for callers not allowed to read salaries.
+type RedactedUser struct {
+	*User
+	Salary *float64 `json:"salary,omitempty"` // always nil, hides User.Salary
+}
+
+// RedactSalaries wraps users so they serialize without salaries.
+func RedactSalaries(users []*User) []*RedactedUser {
+	redacted := make([]*RedactedUser, len(users))
+	for i, user := range users {
+		redacted[i] = &RedactedUser{User: user}
+	}
+	return redacted
+}
+
+// UserInput is the request body accepted when creating or patching a user.
+// Nil fields are left untouched on PATCH.
+type UserInput struct {
+	Name         *string  `json:"name"`
+	Email        *string  `json:"email"`
+	DepartmentID *string  `json:"department_id"`
+	Age          *int     `json:"age"`
+	Salary       *float64 `json:"salary"`
+}
+
+// DegradedPoints marks a response whose points could not be fetched from point_service.
+const DegradedPoints = "points"
+
+// FieldStatistics summarizes the non-null values of one column. Median and P90 interpolate between
+// the closest values, and StdDev is the sample standard deviation. All are 0 when Count is 0.
+type FieldStatistics struct {
+	Count  int64   `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	StdDev float64 `json:"stddev"`
+}
+
+// UserAggregates holds the age and salary statistics over UserCount users.
+type UserAggregates struct {
+	UserCount int64
+	Age       FieldStatistics
+	Salary    FieldStatistics
+}
+
+// DepartmentUserAggregates holds the statistics of the users of one department. DepartmentID is nil
+// for the users without a department.
+type DepartmentUserAggregates struct {
+	DepartmentID *string
+	UserAggregates
 }
 
 type UserStatistics struct {
-	UserList       []*User
-	Count          string
-	UserHighAge    int
-	UserLowAge     int
-	UserAvgAge     float64
-	UserLowSalary  float64
-	UserHighSalary float64
-	UserAvgSalary  float64
+	UserList   []*User
+	Count      string
+	Aggregates UserAggregates
+	Degraded   []string
 }
 
 type ResponseUser struct {
-	HighAge    string      `json:"high_age" `
-	LowAge     string      `json:"low_age" `
-	AvgAge     string      `json:"avg_age" `
-	HighSalary string      `json:"high_salary" `
-	LowSalary  string      `json:"low_salary" `
-	AvgSalary  string      `json:"avg_salary" `
-	Count      string      `json:"count" `
-	List       interface{} `json:"list" `
+	HighAge      string      `json:"high_age" `
+	LowAge       string      `json:"low_age" `
+	AvgAge       string      `json:"avg_age" `
+	HighSalary   string      `json:"high_salary,omitempty" mapstructure:"HighSalary,omitempty"`
+	LowSalary    string      `json:"low_salary,omitempty" mapstructure:"LowSalary,omitempty"`
+	AvgSalary    string      `json:"avg_salary,omitempty" mapstructure:"AvgSalary,omitempty"`
+	Count        string      `json:"count" `
+	MedianAge    string      `json:"median_age"`
+	P90Age       string      `json:"p90_age"`
+	StdDevAge    string      `json:"stddev_age"`
+	MedianSalary string      `json:"median_salary,omitempty" mapstructure:"MedianSalary,omitempty"`
+	P90Salary    string      `json:"p90_salary,omitempty" mapstructure:"P90Salary,omitempty"`
+	StdDevSalary string      `json:"stddev_salary,omitempty" mapstructure:"StdDevSalary,omitempty"`
+	UserCount    int64       `json:"user_count"`
+	AgeCount     int64       `json:"age_count"`
+	SalaryCount  int64       `json:"salary_count"`
+	StatsScope   string      `json:"stats_scope"`
+	List         interface{} `json:"list" `
+	Degraded     []string    `json:"degraded,omitempty" mapstructure:"Degraded,omitempty"`
+	NextCursor   string      `json:"next_cursor,omitempty" mapstructure:"NextCursor,omitempty"`
+	PrevCursor   string      `json:"prev_cursor,omitempty" mapstructure:"PrevCursor,omitempty"`
+}
+
+// RedactSalaries drops the salary statistics and the salaries of the listed users.
+func (r *ResponseUser) RedactSalaries() {
+	r.HighSalary, r.LowSalary, r.AvgSalary = "", "", ""
+	r.MedianSalary, r.P90Salary, r.StdDevSalary = "", "", ""
+	if users, ok := r.List.([]*User); ok {
+		r.List = RedactSalaries(users)
+	}
+}
+
+// ResponseUserStats is the body of the user statistics endpoint. Departments is only set when the
+// statistics are grouped by department.
+type ResponseUserStats struct {
+	UserCount   int64                      `json:"user_count"`
+	Age         FieldStatistics            `json:"age"`
+	Salary      *FieldStatistics           `json:"salary,omitempty"`
+	Departments []*ResponseDepartmentStats `json:"departments,omitempty"`
+}
+
+// ResponseDepartmentStats holds the statistics of one department. DepartmentID is null for the
+// users without a department.
+type ResponseDepartmentStats struct {
+	DepartmentID *string          `json:"department_id"`
+	UserCount    int64            `json:"user_count"`
+	Age          FieldStatistics  `json:"age"`
+	Salary       *FieldStatistics `json:"salary,omitempty"`
+}
+
+// NewResponseUserStats builds the response for the statistics of all users and of each of departments.
+func NewResponseUserStats(aggregates *UserAggregates, departments []*DepartmentUserAggregates) *ResponseUserStats {
+	r := &ResponseUserStats{UserCount: aggregates.UserCount, Age: aggregates.Age, Salary: &aggregates.Salary}
+	for _, department := range departments {
+		r.Departments = append(r.Departments, &ResponseDepartmentStats{
+			DepartmentID: department.DepartmentID,
+			UserCount:    department.UserCount,
+			Age:          department.Age,
+			Salary:       &department.Salary,
+		})
+	}
+	return r
+}
+
+// RedactSalaries drops the salary statistics.
+func (r *ResponseUserStats) RedactSalaries() {
+	r.Salary = nil
+	for _, department := range r.Departments {
+		department.Salary = nil
+	}
 }
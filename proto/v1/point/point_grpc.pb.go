@@ -19,8 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PointServer_GetUserPoints_FullMethodName     = "/point.PointServer/GetUserPoints"
-	PointServer_GetUserListPoints_FullMethodName = "/point.PointServer/GetUserListPoints"
+	PointServer_GetUserPoints_FullMethodName       = "/point.PointServer/GetUserPoints"
+	PointServer_GetUserListPoints_FullMethodName   = "/point.PointServer/GetUserListPoints"
+	PointServer_AwardPoints_FullMethodName         = "/point.PointServer/AwardPoints"
+	PointServer_DeductPoints_FullMethodName        = "/point.PointServer/DeductPoints"
+	PointServer_SetPoints_FullMethodName           = "/point.PointServer/SetPoints"
+	PointServer_GetUserPointHistory_FullMethodName = "/point.PointServer/GetUserPointHistory"
+	PointServer_GetLeaderboard_FullMethodName      = "/point.PointServer/GetLeaderboard"
 )
 
 // PointServerClient is the client API for PointServer service.
@@ -29,6 +34,11 @@ const (
 type PointServerClient interface {
 	GetUserPoints(ctx context.Context, in *PointRequest, opts ...grpc.CallOption) (*PointReply, error)
 	GetUserListPoints(ctx context.Context, in *UserListRequest, opts ...grpc.CallOption) (*UserListPointResponse, error)
+	AwardPoints(ctx context.Context, in *PointMutationRequest, opts ...grpc.CallOption) (*PointBalanceReply, error)
+	DeductPoints(ctx context.Context, in *PointMutationRequest, opts ...grpc.CallOption) (*PointBalanceReply, error)
+	SetPoints(ctx context.Context, in *PointMutationRequest, opts ...grpc.CallOption) (*PointBalanceReply, error)
+	GetUserPointHistory(ctx context.Context, in *PointHistoryRequest, opts ...grpc.CallOption) (*PointHistoryReply, error)
+	GetLeaderboard(ctx context.Context, in *LeaderboardRequest, opts ...grpc.CallOption) (*LeaderboardReply, error)
 }
 
 type pointServerClient struct {
@@ -59,12 +69,67 @@ func (c *pointServerClient) GetUserListPoints(ctx context.Context, in *UserListR
 	return out, nil
 }
 
+func (c *pointServerClient) AwardPoints(ctx context.Context, in *PointMutationRequest, opts ...grpc.CallOption) (*PointBalanceReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PointBalanceReply)
+	err := c.cc.Invoke(ctx, PointServer_AwardPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pointServerClient) DeductPoints(ctx context.Context, in *PointMutationRequest, opts ...grpc.CallOption) (*PointBalanceReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PointBalanceReply)
+	err := c.cc.Invoke(ctx, PointServer_DeductPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pointServerClient) SetPoints(ctx context.Context, in *PointMutationRequest, opts ...grpc.CallOption) (*PointBalanceReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PointBalanceReply)
+	err := c.cc.Invoke(ctx, PointServer_SetPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pointServerClient) GetUserPointHistory(ctx context.Context, in *PointHistoryRequest, opts ...grpc.CallOption) (*PointHistoryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PointHistoryReply)
+	err := c.cc.Invoke(ctx, PointServer_GetUserPointHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pointServerClient) GetLeaderboard(ctx context.Context, in *LeaderboardRequest, opts ...grpc.CallOption) (*LeaderboardReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaderboardReply)
+	err := c.cc.Invoke(ctx, PointServer_GetLeaderboard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PointServerServer is the server API for PointServer service.
 // All implementations must embed UnimplementedPointServerServer
 // for forward compatibility.
 type PointServerServer interface {
 	GetUserPoints(context.Context, *PointRequest) (*PointReply, error)
 	GetUserListPoints(context.Context, *UserListRequest) (*UserListPointResponse, error)
+	AwardPoints(context.Context, *PointMutationRequest) (*PointBalanceReply, error)
+	DeductPoints(context.Context, *PointMutationRequest) (*PointBalanceReply, error)
+	SetPoints(context.Context, *PointMutationRequest) (*PointBalanceReply, error)
+	GetUserPointHistory(context.Context, *PointHistoryRequest) (*PointHistoryReply, error)
+	GetLeaderboard(context.Context, *LeaderboardRequest) (*LeaderboardReply, error)
 	mustEmbedUnimplementedPointServerServer()
 }
 
@@ -81,6 +146,21 @@ func (UnimplementedPointServerServer) GetUserPoints(context.Context, *PointReque
 func (UnimplementedPointServerServer) GetUserListPoints(context.Context, *UserListRequest) (*UserListPointResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserListPoints not implemented")
 }
+func (UnimplementedPointServerServer) AwardPoints(context.Context, *PointMutationRequest) (*PointBalanceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AwardPoints not implemented")
+}
+func (UnimplementedPointServerServer) DeductPoints(context.Context, *PointMutationRequest) (*PointBalanceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeductPoints not implemented")
+}
+func (UnimplementedPointServerServer) SetPoints(context.Context, *PointMutationRequest) (*PointBalanceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPoints not implemented")
+}
+func (UnimplementedPointServerServer) GetUserPointHistory(context.Context, *PointHistoryRequest) (*PointHistoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserPointHistory not implemented")
+}
+func (UnimplementedPointServerServer) GetLeaderboard(context.Context, *LeaderboardRequest) (*LeaderboardReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLeaderboard not implemented")
+}
 func (UnimplementedPointServerServer) mustEmbedUnimplementedPointServerServer() {}
 func (UnimplementedPointServerServer) testEmbeddedByValue()                     {}
 
@@ -138,6 +218,96 @@ func _PointServer_GetUserListPoints_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PointServer_AwardPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PointMutationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PointServerServer).AwardPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PointServer_AwardPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PointServerServer).AwardPoints(ctx, req.(*PointMutationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PointServer_DeductPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PointMutationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PointServerServer).DeductPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PointServer_DeductPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PointServerServer).DeductPoints(ctx, req.(*PointMutationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PointServer_SetPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PointMutationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PointServerServer).SetPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PointServer_SetPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PointServerServer).SetPoints(ctx, req.(*PointMutationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PointServer_GetUserPointHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PointHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PointServerServer).GetUserPointHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PointServer_GetUserPointHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PointServerServer).GetUserPointHistory(ctx, req.(*PointHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PointServer_GetLeaderboard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaderboardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PointServerServer).GetLeaderboard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PointServer_GetLeaderboard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PointServerServer).GetLeaderboard(ctx, req.(*LeaderboardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PointServer_ServiceDesc is the grpc.ServiceDesc for PointServer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +323,26 @@ var PointServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserListPoints",
 			Handler:    _PointServer_GetUserListPoints_Handler,
 		},
+		{
+			MethodName: "AwardPoints",
+			Handler:    _PointServer_AwardPoints_Handler,
+		},
+		{
+			MethodName: "DeductPoints",
+			Handler:    _PointServer_DeductPoints_Handler,
+		},
+		{
+			MethodName: "SetPoints",
+			Handler:    _PointServer_SetPoints_Handler,
+		},
+		{
+			MethodName: "GetUserPointHistory",
+			Handler:    _PointServer_GetUserPointHistory_Handler,
+		},
+		{
+			MethodName: "GetLeaderboard",
+			Handler:    _PointServer_GetLeaderboard_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/v1/point/point.proto",
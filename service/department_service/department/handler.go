@@ -1,10 +1,16 @@
 package department
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
+	"github.com/syedomair/backend-microservices/lib/logging"
 	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/lib/response"
 	"github.com/syedomair/backend-microservices/models"
@@ -12,53 +18,256 @@ import (
 	"go.uber.org/zap"
 )
 
+// sortColumns are the columns departments may be sorted by. A null address sorts as the empty
+// string it is listed with.
+var sortColumns = append(request.NotNull("id", "name"), request.SortColumn{Name: "address", NullAs: "''"})
+
+// filterFields are the columns departments may be filtered on.
+var filterFields = request.FilterFields{
+	"id":      {Type: request.UUID, Operators: []string{request.Eq, request.Ne, request.In}},
+	"name":    {Type: request.String, Operators: []string{request.Eq, request.Ne, request.In, request.Contains}},
+	"address": {Type: request.String, Operators: []string{request.Eq, request.Ne, request.Contains}},
+}
+
 type Controller struct {
-	Logger *zap.Logger
-	Repo   Repository
+	Logger  *zap.Logger
+	Repo    Repository
+	Cursors *request.CursorCodec
 }
 
 // GetAllDepartments retrieves all departments with additional statistics.
 func (c *Controller) GetAllDepartments(w http.ResponseWriter, r *http.Request) {
 	methodName := "GetAllDepartments"
-	c.Logger.Debug("method start", zap.String("method", methodName))
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
 	start := time.Now()
 
-	queryParam, err := request.ValidateQueryString(r, "1000", "0", "name", "asc")
+	queryParam, err := request.ValidateQueryString(r, "1000", "0", "name", "asc", sortColumns, c.Cursors)
 	if err != nil {
-		c.handleError(methodName, w, err, http.StatusBadRequest)
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	if queryParam.Filter, err = request.ValidateFilter(r, filterFields); err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	responseObj, err := c.GetAllDepartmentData(queryParam.Limit, queryParam.Page, queryParam.OrderBy, queryParam.Sort)
+	responseObj, err := c.GetAllDepartmentData(r.Context(), queryParam)
 	if err != nil {
-		c.handleError(methodName, w, err, http.StatusBadRequest)
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	c.Logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
 	response.SuccessResponseHelper(w, responseObj, http.StatusOK)
 }
 
+// SearchDepartments returns the departments whose name or address matches 'q', most relevant first.
+func (c *Controller) SearchDepartments(w http.ResponseWriter, r *http.Request) {
+	methodName := "SearchDepartments"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	term, err := request.ValidateSearchTerm(r)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	// Relevance comes first in the ordering, so results are paged by offset only.
+	queryParam, err := request.ValidateQueryString(r, "20", "0", "name", "asc", sortColumns, nil)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	departments, count, err := c.Repo.SearchDepartmentDB(r.Context(), term, queryParam.Limit, queryParam.Page, queryParam.OrderBy)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseList(w, departments, strconv.Itoa(queryParam.Page), strconv.Itoa(queryParam.Limit), count, "", "")
+}
+
+// CreateDepartment creates a new department.
+func (c *Controller) CreateDepartment(w http.ResponseWriter, r *http.Request) {
+	methodName := "CreateDepartment"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	var input models.DepartmentInput
+	if err := request.DecodeJSONBody(r, &input); err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	if input.Name == nil || strings.TrimSpace(*input.Name) == "" {
+		c.handleError(methodName, w, r, errors.New("'name' is required"), http.StatusBadRequest)
+		return
+	}
+
+	department := &models.Department{Name: strings.TrimSpace(*input.Name)}
+	if input.Address != nil {
+		department.Address = *input.Address
+	}
+
+	department, err := c.Repo.CreateDepartmentDB(r.Context(), department)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, department, http.StatusCreated)
+}
+
+// GetDepartment retrieves a single department by ID.
+func (c *Controller) GetDepartment(w http.ResponseWriter, r *http.Request) {
+	methodName := "GetDepartment"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	departmentID, err := request.ValidatePathUUID(r, "id")
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	department, err := c.Repo.GetDepartmentDB(r.Context(), departmentID)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, department, http.StatusOK)
+}
+
+// UpdateDepartment applies a partial update to a department.
+func (c *Controller) UpdateDepartment(w http.ResponseWriter, r *http.Request) {
+	methodName := "UpdateDepartment"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	departmentID, err := request.ValidatePathUUID(r, "id")
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var input models.DepartmentInput
+	if err := request.DecodeJSONBody(r, &input); err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	fields := make(map[string]interface{})
+	if input.Name != nil {
+		if strings.TrimSpace(*input.Name) == "" {
+			c.handleError(methodName, w, r, errors.New("'name' must not be empty"), http.StatusBadRequest)
+			return
+		}
+		fields["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.Address != nil {
+		fields["address"] = *input.Address
+	}
+	if len(fields) == 0 {
+		c.handleError(methodName, w, r, errors.New("request body must set at least one field"), http.StatusBadRequest)
+		return
+	}
+
+	department, err := c.Repo.UpdateDepartmentDB(r.Context(), departmentID, fields)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, department, http.StatusOK)
+}
+
+// DeleteDepartment removes a department. Assigned users must be moved with
+// ?reassign_to=<department id>, otherwise the request fails with 409.
+func (c *Controller) DeleteDepartment(w http.ResponseWriter, r *http.Request) {
+	methodName := "DeleteDepartment"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	departmentID, err := request.ValidatePathUUID(r, "id")
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reassignTo := r.URL.Query().Get("reassign_to")
+	if reassignTo != "" {
+		if _, err := uuid.Parse(reassignTo); err != nil {
+			c.handleError(methodName, w, r, errors.New("invalid 'reassign_to' value in query string. Must be a UUID. "), http.StatusBadRequest)
+			return
+		}
+		if reassignTo == departmentID {
+			c.handleError(methodName, w, r, errors.New("'reassign_to' must be a different department"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := c.Repo.DeleteDepartmentDB(r.Context(), departmentID, reassignTo); err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, map[string]string{"id": departmentID}, http.StatusOK)
+}
+
+// statusFromError maps repository errors to HTTP status codes.
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrDepartmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrDepartmentInUse):
+		return http.StatusConflict
+	case errors.Is(err, ErrReassignTargetNotFound):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // handleError abstracts error handling logic.
-func (c *Controller) handleError(methodName string, w http.ResponseWriter, err error, statusCode int) {
-	c.Logger.Error("method failed", zap.String("method", methodName), zap.Error(err))
+func (c *Controller) handleError(methodName string, w http.ResponseWriter, r *http.Request, err error, statusCode int) {
+	logging.FromContext(r.Context(), c.Logger).Error("method failed", zap.String("method", methodName), zap.Error(err))
 	response.ErrorResponseHelper(methodName, w, err.Error(), statusCode)
 }
 
 // GetAllDepartmentData fetches department data and statistics concurrently.
-func (c *Controller) GetAllDepartmentData(limit, offset int, orderBy, sort string) (map[string]interface{}, error) {
+func (c *Controller) GetAllDepartmentData(ctx context.Context, queryParam request.QueryParams) (map[string]interface{}, error) {
 	methodName := "GetAllDepartmentData"
-	c.Logger.Debug("method start", zap.String("method", methodName))
+	logger := logging.FromContext(ctx, c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
 	start := time.Now()
 
-	departmentList, count, err := c.Repo.GetAllDepartmentDB(limit, offset, orderBy, sort)
+	departmentList, count, err := c.Repo.GetAllDepartmentDB(ctx, queryParam.FetchLimit(), queryParam.Page, queryParam.OrderBy, queryParam.Keyset, queryParam.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, _ := strconv.Atoi(count)
+	departmentList, cursors, err := request.Paginate(queryParam, departmentList, total)
 	if err != nil {
 		return nil, err
 	}
 
 	responseDepartmentObj := models.ResponseDepartment{
-		Count: count,
-		List:  departmentList,
+		Count:      count,
+		List:       departmentList,
+		NextCursor: cursors.Next,
+		PrevCursor: cursors.Prev,
 	}
 
 	var responseObj map[string]interface{}
@@ -67,6 +276,6 @@ func (c *Controller) GetAllDepartmentData(limit, offset int, orderBy, sort strin
 		return nil, err
 	}
 
-	c.Logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
 	return responseObj, nil
 }
@@ -1,12 +1,17 @@
 package department
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 	"go.uber.org/zap"
 )
@@ -15,7 +20,7 @@ func TestGetAllDepartments_Success(t *testing.T) {
 	// Arrange
 	logger, _ := zap.NewDevelopment()
 	mockRepo := &MockRepository{
-		GetAllDepartmentDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.Department, string, error) {
+		GetAllDepartmentDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error) {
 			return []*models.Department{{ID: "1", Name: "HR", Address: "123 Main St"}}, "1", nil
 		},
 	}
@@ -64,7 +69,7 @@ func TestGetAllDepartments_RepositoryError(t *testing.T) {
 	// Arrange
 	logger, _ := zap.NewDevelopment()
 	mockRepo := &MockRepository{
-		GetAllDepartmentDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.Department, string, error) {
+		GetAllDepartmentDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error) {
 			return nil, "", errors.New("repository error")
 		},
 	}
@@ -85,3 +90,166 @@ func TestGetAllDepartments_RepositoryError(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+const (
+	testDepartmentID = "0b5c1b1e-4f4c-4b43-9d8e-2a8c1f1f6a10"
+	testTargetID     = "9d1f3c2a-6c55-4d0e-8f3b-7f0c2c9e5b21"
+)
+
+// newRequestWithID builds a request carrying the chi {id} URL parameter.
+func newRequestWithID(t *testing.T, method, target, id, body string) *http.Request {
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	assert.NoError(t, err)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCreateDepartment_Success(t *testing.T) {
+	// Arrange
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		CreateDepartmentDBFunc: func(ctx context.Context, department *models.Department) (*models.Department, error) {
+			department.ID = testDepartmentID
+			return department, nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+
+	req, err := http.NewRequest("POST", "/departments", strings.NewReader(`{"name":"Legal","address":"1 Court St"}`))
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	// Act
+	controller.CreateDepartment(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestCreateDepartment_MissingName(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{Logger: logger, Repo: &MockRepository{}}
+
+	req, err := http.NewRequest("POST", "/departments", strings.NewReader(`{"address":"1 Court St"}`))
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	controller.CreateDepartment(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetDepartment_NotFound(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		GetDepartmentDBFunc: func(ctx context.Context, departmentID string) (*models.Department, error) {
+			return nil, ErrDepartmentNotFound
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.GetDepartment(rr, newRequestWithID(t, "GET", "/departments/"+testDepartmentID, testDepartmentID, ""))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateDepartment_Success(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		UpdateDepartmentDBFunc: func(ctx context.Context, departmentID string, fields map[string]interface{}) (*models.Department, error) {
+			assert.Equal(t, "Legal", fields["name"])
+			return &models.Department{ID: departmentID, Name: "Legal"}, nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.UpdateDepartment(rr, newRequestWithID(t, "PATCH", "/departments/"+testDepartmentID, testDepartmentID, `{"name":"Legal"}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDeleteDepartment(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	tests := []struct {
+		name       string
+		query      string
+		repoErr    error
+		wantReassn string
+		want       int
+	}{
+		{name: "no users assigned", query: "", want: http.StatusOK},
+		{name: "users assigned", query: "", repoErr: fmt.Errorf("%w (3 users)", ErrDepartmentInUse), want: http.StatusConflict},
+		{name: "reassign users", query: "?reassign_to=" + testTargetID, wantReassn: testTargetID, want: http.StatusOK},
+		{name: "reassign target missing", query: "?reassign_to=" + testTargetID, wantReassn: testTargetID, repoErr: ErrReassignTargetNotFound, want: http.StatusBadRequest},
+		{name: "reassign to itself", query: "?reassign_to=" + testDepartmentID, want: http.StatusBadRequest},
+		{name: "reassign to invalid id", query: "?reassign_to=finance", want: http.StatusBadRequest},
+		{name: "department missing", query: "", repoErr: ErrDepartmentNotFound, want: http.StatusNotFound},
+		{name: "database failure", query: "", repoErr: errors.New("connection reset"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRepository{
+				DeleteDepartmentDBFunc: func(ctx context.Context, departmentID string, reassignTo string) error {
+					assert.Equal(t, testDepartmentID, departmentID)
+					assert.Equal(t, tt.wantReassn, reassignTo)
+					return tt.repoErr
+				},
+			}
+			controller := &Controller{Logger: logger, Repo: mockRepo}
+			rr := httptest.NewRecorder()
+
+			controller.DeleteDepartment(rr, newRequestWithID(t, "DELETE", "/departments/"+testDepartmentID+tt.query, testDepartmentID, ""))
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestSearchDepartments(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		repoErr    error
+		wantStatus int
+		wantTerm   string
+	}{
+		{name: "Success", query: "?q=+fin+&limit=5&page=10", wantStatus: http.StatusOK, wantTerm: "fin"},
+		{name: "Missing term", query: "", wantStatus: http.StatusBadRequest},
+		{name: "Cursor not supported", query: "?q=fin&cursor=abc", wantStatus: http.StatusBadRequest},
+		{name: "Repository error", query: "?q=fin&limit=5&page=10", repoErr: errors.New("database error"), wantStatus: http.StatusInternalServerError, wantTerm: "fin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			var gotTerm string
+			mockRepo := &MockRepository{
+				SearchDepartmentDBFunc: func(ctx context.Context, term string, limit, offset int, orderBy string) ([]*models.Department, string, error) {
+					gotTerm = term
+					assert.Equal(t, 5, limit)
+					assert.Equal(t, 45, offset)
+					assert.Equal(t, "name ASC, id ASC", orderBy)
+					if tt.repoErr != nil {
+						return nil, "", tt.repoErr
+					}
+					return []*models.Department{{ID: "1", Name: "Finance"}}, "11", nil
+				},
+			}
+			controller := &Controller{Logger: logger, Repo: mockRepo}
+
+			req, err := http.NewRequest("GET", "/departments/search"+tt.query, nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			controller.SearchDepartments(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantTerm, gotTerm)
+			if tt.wantStatus == http.StatusOK {
+				assert.Contains(t, rr.Body.String(), `"count":"11"`)
+			}
+		})
+	}
+}
@@ -1,14 +1,41 @@
 package department
 
 import (
+	"context"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 )
 
 // MockRepository is a manual mock implementation of the Repository interface.
 type MockRepository struct {
-	GetAllDepartmentDBFunc func(limit, offset int, orderBy, sort string) ([]*models.Department, string, error)
+	GetAllDepartmentDBFunc func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error)
+	SearchDepartmentDBFunc func(ctx context.Context, term string, limit, offset int, orderBy string) ([]*models.Department, string, error)
+	CreateDepartmentDBFunc func(ctx context.Context, department *models.Department) (*models.Department, error)
+	GetDepartmentDBFunc    func(ctx context.Context, departmentID string) (*models.Department, error)
+	UpdateDepartmentDBFunc func(ctx context.Context, departmentID string, fields map[string]interface{}) (*models.Department, error)
+	DeleteDepartmentDBFunc func(ctx context.Context, departmentID string, reassignTo string) error
 }
 
-func (m *MockRepository) GetAllDepartmentDB(limit, offset int, orderBy, sort string) ([]*models.Department, string, error) {
-	return m.GetAllDepartmentDBFunc(limit, offset, orderBy, sort)
+func (m *MockRepository) GetAllDepartmentDB(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error) {
+	return m.GetAllDepartmentDBFunc(ctx, limit, offset, orderBy, keyset, filter)
+}
+
+func (m *MockRepository) SearchDepartmentDB(ctx context.Context, term string, limit, offset int, orderBy string) ([]*models.Department, string, error) {
+	return m.SearchDepartmentDBFunc(ctx, term, limit, offset, orderBy)
+}
+
+func (m *MockRepository) CreateDepartmentDB(ctx context.Context, department *models.Department) (*models.Department, error) {
+	return m.CreateDepartmentDBFunc(ctx, department)
+}
+
+func (m *MockRepository) GetDepartmentDB(ctx context.Context, departmentID string) (*models.Department, error) {
+	return m.GetDepartmentDBFunc(ctx, departmentID)
+}
+
+func (m *MockRepository) UpdateDepartmentDB(ctx context.Context, departmentID string, fields map[string]interface{}) (*models.Department, error) {
+	return m.UpdateDepartmentDBFunc(ctx, departmentID, fields)
+}
+
+func (m *MockRepository) DeleteDepartmentDB(ctx context.Context, departmentID string, reassignTo string) error {
+	return m.DeleteDepartmentDBFunc(ctx, departmentID, reassignTo)
 }
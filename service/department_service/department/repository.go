@@ -1,10 +1,28 @@
 package department
 
 import (
+	"context"
+	"errors"
+
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 )
 
+var (
+	// ErrDepartmentNotFound is returned when no department matches the given ID.
+	ErrDepartmentNotFound = errors.New("department not found")
+	// ErrDepartmentInUse is returned when deleting a department that still has users assigned.
+	ErrDepartmentInUse = errors.New("department still has users assigned; pass 'reassign_to' to move them first")
+	// ErrReassignTargetNotFound is returned when the 'reassign_to' department does not exist.
+	ErrReassignTargetNotFound = errors.New("'reassign_to' department does not exist")
+)
+
 // Repository interface
 type Repository interface {
-	GetAllDepartmentDB(limit int, offset int, orderby string, sort string) ([]*models.Department, string, error)
+	GetAllDepartmentDB(ctx context.Context, limit int, offset int, orderby string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error)
+	SearchDepartmentDB(ctx context.Context, term string, limit int, offset int, orderby string) ([]*models.Department, string, error)
+	CreateDepartmentDB(ctx context.Context, department *models.Department) (*models.Department, error)
+	GetDepartmentDB(ctx context.Context, departmentID string) (*models.Department, error)
+	UpdateDepartmentDB(ctx context.Context, departmentID string, fields map[string]interface{}) (*models.Department, error)
+	DeleteDepartmentDB(ctx context.Context, departmentID string, reassignTo string) error
 }
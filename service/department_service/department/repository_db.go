@@ -1,42 +1,228 @@
 package department
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/syedomair/backend-microservices/lib/logging"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/lib/search"
 	"github.com/syedomair/backend-microservices/models"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type dbRepo struct {
-	client *gorm.DB
-	logger *zap.Logger
+	client   *gorm.DB
+	logger   *zap.Logger
+	searcher *search.Searcher
 }
 
 // NewDBRepository Public.
 func NewDBRepository(c *gorm.DB, logger *zap.Logger) Repository {
-	return &dbRepo{client: c, logger: logger}
+	return &dbRepo{client: c, logger: logger, searcher: search.New(c)}
 }
 
 // GetAllDepartmentDB Public
-func (p *dbRepo) GetAllDepartmentDB(limit int, offset int, orderby string, sort string) ([]*models.Department, string, error) {
+// The page starts after keyset when it is set, and at offset otherwise. count is the total matching filter.
+func (p *dbRepo) GetAllDepartmentDB(ctx context.Context, limit int, offset int, orderby string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error) {
 	methodName := "GetAllDepartmentDB"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
 	departments := []*models.Department{}
 	count := int64(0)
-	if err := p.client.Table("department").
+	if err := p.filtered(ctx, filter).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+
+	query := p.filtered(ctx, filter).
 		Select("*").
 		Limit(limit).
+		Order(orderby)
+	if keyset != nil {
+		query = query.Where(keyset.Where, keyset.Args...)
+	} else {
+		query = query.Offset(offset)
+	}
+	if err := query.Scan(&departments).Error; err != nil {
+		return nil, "", err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return departments, strconv.Itoa(int(count)), nil
+}
+
+// filtered returns a query on the department table, bound to ctx, restricted to filter, which may be nil.
+func (p *dbRepo) filtered(ctx context.Context, filter *request.Filter) *gorm.DB {
+	query := p.client.WithContext(ctx).Table("department")
+	if where, args := filter.Where(); where != "" {
+		query = query.Where(where, args...)
+	}
+	return query
+}
+
+// SearchDepartmentDB Public
+// Departments match term on name or address and come most relevant first, then in orderby.
+func (p *dbRepo) SearchDepartmentDB(ctx context.Context, term string, limit int, offset int, orderby string) ([]*models.Department, string, error) {
+	methodName := "SearchDepartmentDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	query := p.searcher.Query(ctx, term, "name", "address")
+	departments := []*models.Department{}
+	count := int64(0)
+	if err := p.client.WithContext(ctx).Table("department").Where(query.Where, query.Args...).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+
+	if err := p.client.WithContext(ctx).Table("department").
+		Select("*, "+query.Rank+" AS search_rank", query.RankArgs...).
+		Where(query.Where, query.Args...).
+		Order("search_rank DESC, " + orderby).
+		Limit(limit).
 		Offset(offset).
-		Order(orderby).
-		Scan(&departments).Count(&count).Error; err != nil {
+		Scan(&departments).Error; err != nil {
 		return nil, "", err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
 	return departments, strconv.Itoa(int(count)), nil
 }
+
+// CreateDepartmentDB Public
+func (p *dbRepo) CreateDepartmentDB(ctx context.Context, department *models.Department) (*models.Department, error) {
+	methodName := "CreateDepartmentDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	if department.ID == "" {
+		department.ID = uuid.New().String()
+	}
+	if err := p.client.WithContext(ctx).Table("department").Create(department).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return department, nil
+}
+
+// GetDepartmentDB Public
+func (p *dbRepo) GetDepartmentDB(ctx context.Context, departmentID string) (*models.Department, error) {
+	methodName := "GetDepartmentDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	department, err := getDepartment(p.client.WithContext(ctx), departmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return department, nil
+}
+
+// UpdateDepartmentDB Public
+func (p *dbRepo) UpdateDepartmentDB(ctx context.Context, departmentID string, fields map[string]interface{}) (*models.Department, error) {
+	methodName := "UpdateDepartmentDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	var department *models.Department
+	err := p.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := getDepartment(tx, departmentID); err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			if err := tx.Table("department").
+				Where("id = ?", departmentID).
+				Updates(fields).Error; err != nil {
+				return err
+			}
+		}
+		var err error
+		department, err = getDepartment(tx, departmentID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return department, nil
+}
+
+// DeleteDepartmentDB Public
+// Users assigned to the department block the delete unless reassignTo names
+// another department, in which case they are moved there in the same transaction.
+func (p *dbRepo) DeleteDepartmentDB(ctx context.Context, departmentID string, reassignTo string) error {
+	methodName := "DeleteDepartmentDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	err := p.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the row so no user can be assigned to it until the delete commits.
+		if _, err := getDepartment(tx.Clauses(clause.Locking{Strength: "UPDATE"}), departmentID); err != nil {
+			return err
+		}
+
+		if reassignTo != "" {
+			if _, err := getDepartment(tx, reassignTo); err != nil {
+				if errors.Is(err, ErrDepartmentNotFound) {
+					return ErrReassignTargetNotFound
+				}
+				return err
+			}
+			if err := tx.Table("user").
+				Where("department_id = ?", departmentID).
+				Update("department_id", reassignTo).Error; err != nil {
+				return err
+			}
+		}
+
+		assigned := int64(0)
+		if err := tx.Table("user").
+			Where("department_id = ?", departmentID).
+			Count(&assigned).Error; err != nil {
+			return err
+		}
+		if assigned > 0 {
+			return fmt.Errorf("%w (%d users)", ErrDepartmentInUse, assigned)
+		}
+
+		return tx.Table("department").
+			Where("id = ?", departmentID).
+			Delete(&models.Department{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return nil
+}
+
+func getDepartment(db *gorm.DB, departmentID string) (*models.Department, error) {
+	department := &models.Department{}
+	if err := db.Table("department").
+		Where("id = ?", departmentID).
+		Take(department).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDepartmentNotFound
+		}
+		return nil, err
+	}
+	return department, nil
+}
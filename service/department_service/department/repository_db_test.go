@@ -1,23 +1,25 @@
 package department
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 )
 
 func TestGetAllDepartmentDB_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetAllDepartmentDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.Department, string, error) {
+		GetAllDepartmentDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error) {
 			return []*models.Department{{ID: "1", Name: "HR", Address: "123 Main St"}}, "1", nil
 		},
 	}
 
 	// Act
-	departments, count, err := mockRepo.GetAllDepartmentDB(10, 0, "name", "asc")
+	departments, count, err := mockRepo.GetAllDepartmentDB(context.Background(), 10, 0, "name", nil, nil)
 
 	// Assert
 	assert.NoError(t, err)
@@ -29,16 +31,31 @@ func TestGetAllDepartmentDB_Success(t *testing.T) {
 func TestGetAllDepartmentDB_Error(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetAllDepartmentDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.Department, string, error) {
+		GetAllDepartmentDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.Department, string, error) {
 			return nil, "", errors.New("database error")
 		},
 	}
 
 	// Act
-	departments, count, err := mockRepo.GetAllDepartmentDB(10, 0, "name", "asc")
+	departments, count, err := mockRepo.GetAllDepartmentDB(context.Background(), 10, 0, "name", nil, nil)
 
 	// Assert
 	assert.Error(t, err)
 	assert.Nil(t, departments)
 	assert.Equal(t, "", count)
 }
+
+func TestDeleteDepartmentDB_InUse(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepository{
+		DeleteDepartmentDBFunc: func(ctx context.Context, departmentID string, reassignTo string) error {
+			return ErrDepartmentInUse
+		},
+	}
+
+	// Act
+	err := mockRepo.DeleteDepartmentDB(context.Background(), "1", "")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrDepartmentInUse)
+}
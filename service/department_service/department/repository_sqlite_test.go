@@ -0,0 +1,108 @@
+package department
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/lib/testdb"
+	"github.com/syedomair/backend-microservices/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSQLite_DepartmentCRUD(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+
+	departments, count, err := repo.GetAllDepartmentDB(context.Background(), 10, 0, "name", nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, departments, 3)
+	assert.Equal(t, "3", count)
+
+	created, err := repo.CreateDepartmentDB(context.Background(), &models.Department{Name: "Legal", Address: "1 Court St"})
+	require.NoError(t, err)
+
+	updated, err := repo.UpdateDepartmentDB(context.Background(), created.ID, map[string]interface{}{"address": "2 Court St"})
+	require.NoError(t, err)
+	assert.Equal(t, "2 Court St", updated.Address)
+
+	require.NoError(t, repo.DeleteDepartmentDB(context.Background(), created.ID, ""))
+	_, err = repo.GetDepartmentDB(context.Background(), created.ID)
+	assert.ErrorIs(t, err, ErrDepartmentNotFound)
+}
+
+func TestSQLite_GetAllDepartmentDBCursorPaginationNullAddress(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+	require.NoError(t, db.Exec("INSERT INTO department (name, address) VALUES ('Legal', NULL), ('Audit', NULL)").Error)
+	cursors := request.NewCursorCodec([]byte("secret"))
+
+	for _, orderby := range []string{"address", "-address"} {
+		t.Run(orderby, func(t *testing.T) {
+			names := []string{}
+			query := url.Values{"orderby": {orderby}}
+			for pages := 0; pages < 5; pages++ {
+				req, err := http.NewRequest("GET", "/departments?"+query.Encode(), nil)
+				require.NoError(t, err)
+				params, err := request.ValidateQueryString(req, "2", "0", "name", "asc", sortColumns, cursors)
+				require.NoError(t, err)
+				departments, count, err := repo.GetAllDepartmentDB(context.Background(), params.FetchLimit(), params.Page, params.OrderBy, params.Keyset, nil)
+				require.NoError(t, err)
+				total, err := strconv.Atoi(count)
+				require.NoError(t, err)
+				departments, page, err := request.Paginate(params, departments, total)
+				require.NoError(t, err)
+				for _, department := range departments {
+					names = append(names, department.Name)
+				}
+				if page.Next == "" {
+					break
+				}
+				query = url.Values{"cursor": {page.Next}}
+			}
+			assert.ElementsMatch(t, []string{"Audit", "Finance", "Human Resources", "IT Support", "Legal"}, names)
+		})
+	}
+}
+
+func TestSQLite_DeleteDepartmentWithUsers(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+
+	finance := models.Department{}
+	require.NoError(t, db.Where("name = ?", "Finance").Take(&finance).Error)
+	hr := models.Department{}
+	require.NoError(t, db.Where("name = ?", "Human Resources").Take(&hr).Error)
+
+	err := repo.DeleteDepartmentDB(context.Background(), finance.ID, "")
+	assert.ErrorIs(t, err, ErrDepartmentInUse)
+
+	err = repo.DeleteDepartmentDB(context.Background(), finance.ID, "00000000-0000-4000-8000-000000000000")
+	assert.ErrorIs(t, err, ErrReassignTargetNotFound)
+
+	require.NoError(t, repo.DeleteDepartmentDB(context.Background(), finance.ID, hr.ID))
+
+	moved := int64(0)
+	require.NoError(t, db.Model(&models.User{}).Where("department_id = ?", hr.ID).Count(&moved).Error)
+	assert.Equal(t, int64(6), moved)
+}
+
+func TestSQLite_SearchDepartmentDB(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+
+	departments, count, err := repo.SearchDepartmentDB(context.Background(), "springfield", 2, 0, "name ASC, id ASC")
+	require.NoError(t, err)
+	assert.Equal(t, "3", count)
+	assert.Equal(t, []string{"Finance", "Human Resources"}, []string{departments[0].Name, departments[1].Name})
+
+	departments, count, err = repo.SearchDepartmentDB(context.Background(), "i", 10, 0, "name ASC, id ASC")
+	require.NoError(t, err)
+	assert.Equal(t, "3", count)
+	assert.Equal(t, "IT Support", departments[0].Name, "prefix matches rank first")
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"github.com/syedomair/backend-microservices/lib/auth"
 	"github.com/syedomair/backend-microservices/lib/container"
 	"github.com/syedomair/backend-microservices/lib/router"
 	"github.com/syedomair/backend-microservices/service/department_service/department"
 )
 
+// defaultRateLimit applies to every endpoint without a RateLimit of its own.
+var defaultRateLimit = router.RateLimit{Rate: 10, Burst: 50}
+
 func EndPointConf(c container.Container) []router.EndPoint {
 
 	departmentController := department.Controller{
-		Logger: c.Logger(),
-		Repo:   department.NewDBRepository(c.Db(), c.Logger()),
+		Logger:  c.Logger(),
+		Repo:    department.NewDBRepository(c.Db(), c.Logger()),
+		Cursors: c.CursorCodec(),
 	}
 
 	return []router.EndPoint{
@@ -19,6 +24,42 @@ func EndPointConf(c container.Container) []router.EndPoint {
 			Method:      router.Get,
 			Pattern:     "/departments",
 			HandlerFunc: departmentController.GetAllDepartments,
+			Permissions: []string{auth.PermDepartmentsRead},
+		},
+		{
+			Name:        "SearchDepartments",
+			Method:      router.Get,
+			Pattern:     "/departments/search",
+			HandlerFunc: departmentController.SearchDepartments,
+			Permissions: []string{auth.PermDepartmentsRead},
+		},
+		{
+			Name:        "CreateDepartment",
+			Method:      router.Post,
+			Pattern:     "/departments",
+			HandlerFunc: departmentController.CreateDepartment,
+			Permissions: []string{auth.PermDepartmentsWrite},
+		},
+		{
+			Name:        "GetDepartment",
+			Method:      router.Get,
+			Pattern:     "/departments/{id}",
+			HandlerFunc: departmentController.GetDepartment,
+			Permissions: []string{auth.PermDepartmentsRead},
+		},
+		{
+			Name:        "UpdateDepartment",
+			Method:      router.Patch,
+			Pattern:     "/departments/{id}",
+			HandlerFunc: departmentController.UpdateDepartment,
+			Permissions: []string{auth.PermDepartmentsWrite},
+		},
+		{
+			Name:        "DeleteDepartment",
+			Method:      router.Delete,
+			Pattern:     "/departments/{id}",
+			HandlerFunc: departmentController.DeleteDepartment,
+			Permissions: []string{auth.PermDepartmentsWrite},
 		},
 	}
 }
@@ -18,20 +18,7 @@ import (
 )
 
 func main() {
-	c, err := container.New(map[string]string{
-		container.LogLevel:      os.Getenv(container.LogLevel),
-		container.DatabaseURL:   os.Getenv(container.DatabaseURL),
-		container.Port:          os.Getenv(container.Port),
-		container.DBMaxIdle:     os.Getenv(container.DBMaxIdle),
-		container.DBMaxOpen:     os.Getenv(container.DBMaxOpen),
-		container.DBMaxLifeTime: os.Getenv(container.DBMaxLifeTime),
-		container.DBMaxIdleTime: os.Getenv(container.DBMaxIdleTime),
-		container.ZapConf:       os.Getenv(container.ZapConf),
-		container.GormConf:      os.Getenv(container.GormConf),
-		container.PprofEnable:   os.Getenv(container.PprofEnable),
-		container.PointSrvcAddr: os.Getenv(container.PointSrvcAddr),
-		container.PointSrvcMax:  os.Getenv(container.PointSrvcMax),
-	})
+	c, err := container.New(envVars())
 	if err != nil {
 		defer func() {
 			log.Println("server initialization failed error: %w", err)
@@ -62,7 +49,17 @@ func main() {
 	}
 
 	// Create router
-	router := router.NewRouter(c.Logger(), EndPointConf(c))
+	routerOptions := []router.Option{
+		router.WithMetrics(router.MetricsConfig{Namespace: "department_service"}),
+		router.WithDefaultRateLimit(defaultRateLimit),
+	}
+	if authenticator := c.Authenticator(); authenticator != nil {
+		routerOptions = append(routerOptions, router.WithAuthenticator(authenticator))
+	}
+	if apiKeys := c.APIKeys(); len(apiKeys) > 0 {
+		routerOptions = append(routerOptions, router.WithAPIKeys(apiKeys...))
+	}
+	router := router.NewRouter(c.Logger(), EndPointConf(c), routerOptions...)
 
 	if err := Run(router, c); err != nil {
 		log.Fatalf("server error: %v", err)
@@ -70,6 +67,47 @@ func main() {
 	}
 }
 
+// envVars reads the settings passed to container.New from the environment.
+func envVars() map[string]string {
+	return map[string]string{
+		container.LogLevel:                     os.Getenv(container.LogLevel),
+		container.DatabaseURL:                  os.Getenv(container.DatabaseURL),
+		container.DB:                           os.Getenv(container.DB),
+		container.Port:                         os.Getenv(container.Port),
+		container.DBMaxIdle:                    os.Getenv(container.DBMaxIdle),
+		container.DBMaxOpen:                    os.Getenv(container.DBMaxOpen),
+		container.DBMaxLifeTime:                os.Getenv(container.DBMaxLifeTime),
+		container.DBMaxIdleTime:                os.Getenv(container.DBMaxIdleTime),
+		container.ZapConf:                      os.Getenv(container.ZapConf),
+		container.GormConf:                     os.Getenv(container.GormConf),
+		container.PprofEnable:                  os.Getenv(container.PprofEnable),
+		container.PointSrvcAddr:                os.Getenv(container.PointSrvcAddr),
+		container.PointSrvcMax:                 os.Getenv(container.PointSrvcMax),
+		container.DBInitScript:                 os.Getenv(container.DBInitScript),
+		container.MigrateOnStart:               os.Getenv(container.MigrateOnStart),
+		container.PointSrvcMaxAge:              os.Getenv(container.PointSrvcMaxAge),
+		container.PointSrvcHealthCheck:         os.Getenv(container.PointSrvcHealthCheck),
+		container.PointSrvcRetryMaxAttempts:    os.Getenv(container.PointSrvcRetryMaxAttempts),
+		container.PointSrvcRetryInitialBackoff: os.Getenv(container.PointSrvcRetryInitialBackoff),
+		container.PointSrvcRetryMaxBackoff:     os.Getenv(container.PointSrvcRetryMaxBackoff),
+		container.PointSrvcRetryAttemptTimeout: os.Getenv(container.PointSrvcRetryAttemptTimeout),
+		container.PointSrvcHedgingDelay:        os.Getenv(container.PointSrvcHedgingDelay),
+		container.AuthEnabled:                  os.Getenv(container.AuthEnabled),
+		container.AuthHMACSecret:               os.Getenv(container.AuthHMACSecret),
+		container.AuthRSAPublicKeyFile:         os.Getenv(container.AuthRSAPublicKeyFile),
+		container.AuthJWKSFile:                 os.Getenv(container.AuthJWKSFile),
+		container.AuthIssuer:                   os.Getenv(container.AuthIssuer),
+		container.AuthAudience:                 os.Getenv(container.AuthAudience),
+		container.CursorSecret:                 os.Getenv(container.CursorSecret),
+		container.APIKeys:                      os.Getenv(container.APIKeys),
+		container.TracingServiceName:           os.Getenv(container.TracingServiceName),
+		container.TracingExporter:              os.Getenv(container.TracingExporter),
+		container.TracingFile:                  os.Getenv(container.TracingFile),
+		container.TracingEndpoint:              os.Getenv(container.TracingEndpoint),
+		container.TracingSampleRatio:           os.Getenv(container.TracingSampleRatio),
+	}
+}
+
 func Run(router *chi.Mux, c container.Container) error {
 
 	// Configure server
@@ -101,6 +139,9 @@ func Run(router *chi.Mux, c container.Container) error {
 		c.Logger().Error("server shutdown failed", zap.Error(err))
 		return err
 	}
+	if err := c.ShutdownTracing(ctx); err != nil {
+		c.Logger().Error("tracing shutdown failed", zap.Error(err))
+	}
 	c.Logger().Info("server stopped")
 	return nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/auth"
 	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -43,6 +46,22 @@ func (c *MockContainer) PointServicePool() container.ConnectionPoolInterface {
 	return c.pointServicePool
 }
 
+func (c *MockContainer) Authenticator() *auth.Verifier {
+	return nil
+}
+
+func (c *MockContainer) CursorCodec() *request.CursorCodec {
+	return nil
+}
+
+func (c *MockContainer) APIKeys() []string {
+	return nil
+}
+
+func (c *MockContainer) ShutdownTracing(ctx context.Context) error {
+	return nil
+}
+
 func TestRun(t *testing.T) {
 	// Create a mock logger
 	logger, _ := zap.NewDevelopment()
@@ -89,3 +108,27 @@ func TestRun(t *testing.T) {
 	// Wait for the server to shut down
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestEnvVars_APIKeys(t *testing.T) {
+	for key, value := range map[string]string{
+		container.DBMaxIdle:     "10",
+		container.DBMaxOpen:     "100",
+		container.DBMaxLifeTime: "1",
+		container.DBMaxIdleTime: "10",
+		container.DatabaseURL:   "file:department_serviceapikeys?mode=memory",
+		container.DB:            "SQLITE",
+		container.GormConf:      "../../config/gorm-logger-config.json",
+		container.ZapConf:       "../../config/zap-logger-config.json",
+		container.Port:          "8080",
+		container.PprofEnable:   "false",
+		container.PointSrvcAddr: "point_service:8185",
+		container.PointSrvcMax:  "10",
+		container.APIKeys:       "key-1,key-2",
+	} {
+		t.Setenv(key, value)
+	}
+
+	c, err := container.New(envVars())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key-1", "key-2"}, c.APIKeys())
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"os"
 
@@ -15,18 +17,33 @@ import (
 
 func main() {
 	c, err := container.New(map[string]string{
-		container.LogLevel:      os.Getenv(container.LogLevel),
-		container.DatabaseURL:   os.Getenv(container.DatabaseURL),
-		container.Port:          os.Getenv(container.Port),
-		container.DBMaxIdle:     os.Getenv(container.DBMaxIdle),
-		container.DBMaxOpen:     os.Getenv(container.DBMaxOpen),
-		container.DBMaxLifeTime: os.Getenv(container.DBMaxLifeTime),
-		container.DBMaxIdleTime: os.Getenv(container.DBMaxIdleTime),
-		container.ZapConf:       os.Getenv(container.ZapConf),
-		container.GormConf:      os.Getenv(container.GormConf),
-		container.PprofEnable:   os.Getenv(container.PprofEnable),
-		container.PointSrvcAddr: os.Getenv(container.PointSrvcAddr),
-		container.PointSrvcMax:  os.Getenv(container.PointSrvcMax),
+		container.LogLevel:                     os.Getenv(container.LogLevel),
+		container.DatabaseURL:                  os.Getenv(container.DatabaseURL),
+		container.DB:                           os.Getenv(container.DB),
+		container.Port:                         os.Getenv(container.Port),
+		container.DBMaxIdle:                    os.Getenv(container.DBMaxIdle),
+		container.DBMaxOpen:                    os.Getenv(container.DBMaxOpen),
+		container.DBMaxLifeTime:                os.Getenv(container.DBMaxLifeTime),
+		container.DBMaxIdleTime:                os.Getenv(container.DBMaxIdleTime),
+		container.ZapConf:                      os.Getenv(container.ZapConf),
+		container.GormConf:                     os.Getenv(container.GormConf),
+		container.PprofEnable:                  os.Getenv(container.PprofEnable),
+		container.PointSrvcAddr:                os.Getenv(container.PointSrvcAddr),
+		container.PointSrvcMax:                 os.Getenv(container.PointSrvcMax),
+		container.DBInitScript:                 os.Getenv(container.DBInitScript),
+		container.MigrateOnStart:               os.Getenv(container.MigrateOnStart),
+		container.PointSrvcMaxAge:              os.Getenv(container.PointSrvcMaxAge),
+		container.PointSrvcHealthCheck:         os.Getenv(container.PointSrvcHealthCheck),
+		container.PointSrvcRetryMaxAttempts:    os.Getenv(container.PointSrvcRetryMaxAttempts),
+		container.PointSrvcRetryInitialBackoff: os.Getenv(container.PointSrvcRetryInitialBackoff),
+		container.PointSrvcRetryMaxBackoff:     os.Getenv(container.PointSrvcRetryMaxBackoff),
+		container.PointSrvcRetryAttemptTimeout: os.Getenv(container.PointSrvcRetryAttemptTimeout),
+		container.PointSrvcHedgingDelay:        os.Getenv(container.PointSrvcHedgingDelay),
+		container.TracingServiceName:           os.Getenv(container.TracingServiceName),
+		container.TracingExporter:              os.Getenv(container.TracingExporter),
+		container.TracingFile:                  os.Getenv(container.TracingFile),
+		container.TracingEndpoint:              os.Getenv(container.TracingEndpoint),
+		container.TracingSampleRatio:           os.Getenv(container.TracingSampleRatio),
 	})
 	if err != nil {
 		defer func() {
@@ -58,4 +75,10 @@ func main() {
 		server.GracefulStop()
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.ShutdownTracing(ctx); err != nil {
+		c.Logger().Error("tracing shutdown failed", zap.Error(err))
+	}
+
 }
@@ -7,12 +7,20 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/pkg/errors"
 	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/logging"
 	pb "github.com/syedomair/backend-microservices/proto/v1/point"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
@@ -27,12 +35,18 @@ type Server interface {
 type PointHandler interface {
 	GetUserPoints(ctx context.Context, in *pb.PointRequest) (*pb.PointReply, error)
 	GetUserListPoints(ctx context.Context, in *pb.UserListRequest) (*pb.UserListPointResponse, error)
+	AwardPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error)
+	DeductPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error)
+	SetPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error)
+	GetUserPointHistory(ctx context.Context, in *pb.PointHistoryRequest) (*pb.PointHistoryReply, error)
+	GetLeaderboard(ctx context.Context, in *pb.LeaderboardRequest) (*pb.LeaderboardReply, error)
 }
 
 type server struct {
-	listener   net.Listener
-	grpcServer *grpc.Server
-	handler    PointHandler
+	listener     net.Listener
+	grpcServer   *grpc.Server
+	healthServer *health.Server
+	handler      PointHandler
 }
 
 type pointHandler struct {
@@ -42,40 +56,168 @@ type pointHandler struct {
 }
 
 // GetUserPoints
-func (p *pointHandler) GetUserPoints(_ context.Context, in *pb.PointRequest) (*pb.PointReply, error) {
+func (p *pointHandler) GetUserPoints(ctx context.Context, in *pb.PointRequest) (*pb.PointReply, error) {
 	methodName := "GetUserPoints"
-	p.container.Logger().Debug("method start", zap.String("method", methodName))
+	logger := logging.FromContext(ctx, p.container.Logger())
+	logger.Debug("method start", zap.String("method", methodName))
 	start := time.Now()
 
-	userPoint, err := p.service.GetUserPoints(in.GetUserId())
+	userPoint, err := p.service.GetUserPoints(ctx, in.GetUserId())
 	if err != nil {
 		return nil, err
 	}
 
-	p.container.Logger().Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
 	return &pb.PointReply{UserPoint: strconv.Itoa(userPoint)}, nil
 }
 
 // GetUserListPoints
-func (p *pointHandler) GetUserListPoints(_ context.Context, in *pb.UserListRequest) (*pb.UserListPointResponse, error) {
+func (p *pointHandler) GetUserListPoints(ctx context.Context, in *pb.UserListRequest) (*pb.UserListPointResponse, error) {
 	methodName := "GetUserListPoints"
-	p.container.Logger().Debug("method start", zap.String("method", methodName))
+	logger := logging.FromContext(ctx, p.container.Logger())
+	logger.Debug("method start", zap.String("method", methodName))
 	start := time.Now()
 
-	userPoint, err := p.service.GetUserListPoints(in.GetUserIds())
+	userPoint, err := p.service.GetUserListPoints(ctx, in.GetUserIds())
 	if err != nil {
 		return nil, err
 	}
 
-	p.container.Logger().Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
 	return &pb.UserListPointResponse{UserPoints: userPoint}, nil
 }
 
+// AwardPoints
+func (p *pointHandler) AwardPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error) {
+	return p.mutatePoints(ctx, "AwardPoints", in, p.service.AwardPoints)
+}
+
+// DeductPoints
+func (p *pointHandler) DeductPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error) {
+	return p.mutatePoints(ctx, "DeductPoints", in, p.service.DeductPoints)
+}
+
+// SetPoints
+func (p *pointHandler) SetPoints(ctx context.Context, in *pb.PointMutationRequest) (*pb.PointBalanceReply, error) {
+	return p.mutatePoints(ctx, "SetPoints", in, p.service.SetPoints)
+}
+
+// GetUserPointHistory
+func (p *pointHandler) GetUserPointHistory(ctx context.Context, in *pb.PointHistoryRequest) (*pb.PointHistoryReply, error) {
+	methodName := "GetUserPointHistory"
+	logger := logging.FromContext(ctx, p.container.Logger())
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	if _, err := uuid.Parse(in.GetUserId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be a valid UUID")
+	}
+	limit, err := normalizeHistoryPage(int(in.GetLimit()), int(in.GetOffset()))
+	if err != nil {
+		return nil, status.Error(codeFromError(err), err.Error())
+	}
+
+	transactions, total, err := p.service.GetUserPointHistory(ctx, in.GetUserId(), limit, int(in.GetOffset()))
+	if err != nil {
+		logger.Error("points history failed", zap.String("method", methodName), zap.Error(err))
+		return nil, status.Error(codeFromError(err), err.Error())
+	}
+
+	reply := &pb.PointHistoryReply{
+		Transactions: make([]*pb.PointTransaction, 0, len(transactions)),
+		Total:        total,
+		Limit:        int32(limit),
+		Offset:       in.GetOffset(),
+	}
+	for _, t := range transactions {
+		reply.Transactions = append(reply.Transactions, &pb.PointTransaction{
+			Id:           t.ID,
+			UserId:       t.UserID,
+			Delta:        int32(t.Delta),
+			BalanceAfter: int32(t.BalanceAfter),
+			Reason:       t.Reason,
+			Actor:        t.Actor,
+			CreatedAt:    timestamppb.New(t.CreatedAt),
+		})
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	return reply, nil
+}
+
+// GetLeaderboard
+func (p *pointHandler) GetLeaderboard(ctx context.Context, in *pb.LeaderboardRequest) (*pb.LeaderboardReply, error) {
+	methodName := "GetLeaderboard"
+	logger := logging.FromContext(ctx, p.container.Logger())
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	if in.GetDepartmentId() != "" {
+		if _, err := uuid.Parse(in.GetDepartmentId()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "department_id must be a valid UUID")
+		}
+	}
+
+	entries, err := p.service.GetLeaderboard(ctx, int(in.GetLimit()), in.GetDepartmentId())
+	if err != nil {
+		logger.Error("leaderboard failed", zap.String("method", methodName), zap.Error(err))
+		return nil, status.Error(codeFromError(err), err.Error())
+	}
+
+	reply := &pb.LeaderboardReply{Entries: make([]*pb.LeaderboardEntry, 0, len(entries))}
+	for _, entry := range entries {
+		reply.Entries = append(reply.Entries, &pb.LeaderboardEntry{
+			Rank:   int32(entry.Rank),
+			UserId: entry.UserID,
+			Points: int32(entry.Points),
+		})
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	return reply, nil
+}
+
+func (p *pointHandler) mutatePoints(ctx context.Context, methodName string, in *pb.PointMutationRequest, mutate func(ctx context.Context, userID string, points int, reason, actor string) (int, error)) (*pb.PointBalanceReply, error) {
+	logger := logging.FromContext(ctx, p.container.Logger())
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	if _, err := uuid.Parse(in.GetUserId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be a valid UUID")
+	}
+
+	balance, err := mutate(ctx, in.GetUserId(), int(in.GetPoints()), in.GetReason(), in.GetActor())
+	if err != nil {
+		logger.Error("points mutation failed", zap.String("method", methodName), zap.Error(err))
+		return nil, status.Error(codeFromError(err), err.Error())
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	return &pb.PointBalanceReply{UserId: in.GetUserId(), Balance: int32(balance)}, nil
+}
+
+func codeFromError(err error) codes.Code {
+	switch {
+	case errors.Is(err, ErrInvalidPoints), errors.Is(err, ErrMissingAudit), errors.Is(err, ErrInvalidPagination), errors.Is(err, ErrInvalidLimit):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrUserNotFound):
+		return codes.NotFound
+	case errors.Is(err, ErrInsufficientPoints), errors.Is(err, ErrBalanceOverflow):
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
+
 func (s *server) Serve() error {
 	return s.grpcServer.Serve(s.listener)
 }
 
 func (s *server) GracefulStop() {
+	// Report NOT_SERVING first so pooled clients stop picking this server while it drains.
+	if s.healthServer != nil {
+		s.healthServer.Shutdown()
+	}
 	s.grpcServer.GracefulStop()
 }
 
@@ -104,8 +246,11 @@ func NewServer(c container.Container) (Server, error) {
 	server.handler = handler
 
 	s := grpc.NewServer(
+		// Continues the trace of the caller from the W3C trace context in the request metadata.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
 			grpc_prometheus.UnaryServerInterceptor,
+			logging.UnaryServerInterceptor(c.Logger()),
 		),
 	)
 	grpc_prometheus.Register(s)
@@ -114,5 +259,9 @@ func NewServer(c container.Container) (Server, error) {
 	//server.grpcServer = grpc.NewServer()
 	pb.RegisterPointServerServer(server.grpcServer, handler)
 
+	server.healthServer = health.NewServer()
+	server.healthServer.SetServingStatus(pb.PointServer_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server.grpcServer, server.healthServer)
+
 	return server, nil
 }
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/syedomair/backend-microservices/lib/auth"
 	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/models"
 	pb "github.com/syedomair/backend-microservices/proto/v1/point"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 )
 
@@ -21,16 +27,41 @@ type mockPointService struct {
 
 var _ PointServiceInterface = (*mockPointService)(nil)
 
-func (m *mockPointService) GetUserPoints(userId string) (int, error) {
+func (m *mockPointService) GetUserPoints(_ context.Context, userId string) (int, error) {
 	args := m.Called(userId)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *mockPointService) GetUserListPoints(userIDs []string) (map[string]int32, error) {
+func (m *mockPointService) GetUserListPoints(_ context.Context, userIDs []string) (map[string]int32, error) {
 	args := m.Called(userIDs)
 	return args.Get(0).(map[string]int32), args.Error(1)
 }
 
+func (m *mockPointService) AwardPoints(_ context.Context, userID string, points int, reason, actor string) (int, error) {
+	args := m.Called(userID, points, reason, actor)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPointService) DeductPoints(_ context.Context, userID string, points int, reason, actor string) (int, error) {
+	args := m.Called(userID, points, reason, actor)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPointService) SetPoints(_ context.Context, userID string, points int, reason, actor string) (int, error) {
+	args := m.Called(userID, points, reason, actor)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPointService) GetUserPointHistory(_ context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error) {
+	args := m.Called(userID, limit, offset)
+	return args.Get(0).([]models.PointTransaction), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockPointService) GetLeaderboard(_ context.Context, limit int, departmentID string) ([]*models.LeaderboardEntry, error) {
+	args := m.Called(limit, departmentID)
+	return args.Get(0).([]*models.LeaderboardEntry), args.Error(1)
+}
+
 // Mock Container
 type mockContainer struct {
 	mock.Mock
@@ -56,6 +87,26 @@ func (m *mockContainer) PprofEnable() string {
 	args := m.Called()
 	return args.String(0)
 }
+func (m *mockContainer) Authenticator() *auth.Verifier {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*auth.Verifier)
+}
+func (m *mockContainer) CursorCodec() *request.CursorCodec {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*request.CursorCodec)
+}
+func (m *mockContainer) APIKeys() []string {
+	return nil
+}
+func (m *mockContainer) ShutdownTracing(ctx context.Context) error {
+	return nil
+}
 func (m *mockContainer) PointServicePool() container.ConnectionPoolInterface {
 	args := m.Called()
 	if args.Get(0) == nil {
@@ -128,3 +179,155 @@ func TestGetUserListPoints(t *testing.T) {
 		//mockService.AssertExpectations(t)
 	})
 }
+
+func TestMutatePoints(t *testing.T) {
+	mockService := new(mockPointService)
+	mockContainer := new(mockContainer)
+	mockContainer.On("Logger").Return(t)
+
+	handler := &pointHandler{
+		container: mockContainer,
+		service:   mockService,
+	}
+	userID := "0b1e2e6c-5a4f-4a55-9d3e-4c1b8f6a2d10"
+
+	t.Run("award success", func(t *testing.T) {
+		mockService.On("AwardPoints", userID, 25, "bonus", "admin").Return(125, nil).Once()
+
+		resp, err := handler.AwardPoints(context.Background(), &pb.PointMutationRequest{UserId: userID, Points: 25, Reason: "bonus", Actor: "admin"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, userID, resp.UserId)
+		assert.Equal(t, int32(125), resp.Balance)
+	})
+
+	t.Run("set success", func(t *testing.T) {
+		mockService.On("SetPoints", userID, 40, "correction", "admin").Return(40, nil).Once()
+
+		resp, err := handler.SetPoints(context.Background(), &pb.PointMutationRequest{UserId: userID, Points: 40, Reason: "correction", Actor: "admin"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(40), resp.Balance)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		resp, err := handler.DeductPoints(context.Background(), &pb.PointMutationRequest{UserId: "not-a-uuid", Points: 5})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	errorCases := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"invalid amount", ErrInvalidPoints, codes.InvalidArgument},
+		{"missing audit", ErrMissingAudit, codes.InvalidArgument},
+		{"user not found", ErrUserNotFound, codes.NotFound},
+		{"insufficient points", ErrInsufficientPoints, codes.FailedPrecondition},
+		{"internal", errors.New("db error"), codes.Internal},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService.On("DeductPoints", userID, 5, "redeem", "shop").Return(0, tc.err).Once()
+
+			resp, err := handler.DeductPoints(context.Background(), &pb.PointMutationRequest{UserId: userID, Points: 5, Reason: "redeem", Actor: "shop"})
+
+			assert.Nil(t, resp)
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestGetUserPointHistory(t *testing.T) {
+	mockService := new(mockPointService)
+	mockContainer := new(mockContainer)
+	mockContainer.On("Logger").Return(t)
+
+	handler := &pointHandler{
+		container: mockContainer,
+		service:   mockService,
+	}
+	userID := "0b1e2e6c-5a4f-4a55-9d3e-4c1b8f6a2d10"
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("success with default limit", func(t *testing.T) {
+		transactions := []models.PointTransaction{
+			{ID: "t2", UserID: userID, Delta: -5, BalanceAfter: 20, Reason: "redeem", Actor: "shop", CreatedAt: createdAt},
+			{ID: "t1", UserID: userID, Delta: 25, BalanceAfter: 25, Reason: "bonus", Actor: "admin", CreatedAt: createdAt.Add(-time.Hour)},
+		}
+		mockService.On("GetUserPointHistory", userID, defaultHistoryLimit, 0).Return(transactions, int64(2), nil).Once()
+
+		resp, err := handler.GetUserPointHistory(context.Background(), &pb.PointHistoryRequest{UserId: userID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Total)
+		assert.Equal(t, int32(defaultHistoryLimit), resp.Limit)
+		assert.Len(t, resp.Transactions, 2)
+		assert.Equal(t, int32(-5), resp.Transactions[0].Delta)
+		assert.Equal(t, "redeem", resp.Transactions[0].Reason)
+		assert.True(t, createdAt.Equal(resp.Transactions[0].CreatedAt.AsTime()))
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		resp, err := handler.GetUserPointHistory(context.Background(), &pb.PointHistoryRequest{UserId: userID, Offset: -1})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		resp, err := handler.GetUserPointHistory(context.Background(), &pb.PointHistoryRequest{UserId: "123"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	mockService.AssertExpectations(t)
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	mockService := new(mockPointService)
+	mockContainer := new(mockContainer)
+	mockContainer.On("Logger").Return(t)
+
+	handler := &pointHandler{
+		container: mockContainer,
+		service:   mockService,
+	}
+	departmentID := "6f1c3a52-8b7e-4d0a-9c1e-2f5b7d9e0a11"
+
+	t.Run("success", func(t *testing.T) {
+		entries := []*models.LeaderboardEntry{
+			{Rank: 1, UserID: "u1", Points: 30},
+			{Rank: 2, UserID: "u2", Points: 20},
+			{Rank: 2, UserID: "u3", Points: 20},
+		}
+		mockService.On("GetLeaderboard", 3, departmentID).Return(entries, nil).Once()
+
+		resp, err := handler.GetLeaderboard(context.Background(), &pb.LeaderboardRequest{Limit: 3, DepartmentId: departmentID})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Entries, 3)
+		assert.Equal(t, int32(2), resp.Entries[2].Rank)
+		assert.Equal(t, "u3", resp.Entries[2].UserId)
+	})
+
+	t.Run("invalid department id", func(t *testing.T) {
+		resp, err := handler.GetLeaderboard(context.Background(), &pb.LeaderboardRequest{DepartmentId: "hr"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		mockService.On("GetLeaderboard", -1, "").Return([]*models.LeaderboardEntry(nil), ErrInvalidLimit).Once()
+
+		resp, err := handler.GetLeaderboard(context.Background(), &pb.LeaderboardRequest{Limit: -1})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	mockService.AssertExpectations(t)
+}
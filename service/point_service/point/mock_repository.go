@@ -1,14 +1,40 @@
 package point
 
+import (
+	"context"
+
+	"github.com/syedomair/backend-microservices/models"
+)
+
 // MockRepository is a manual mock implementation of the Repository interface.
 type MockRepositoryDB struct {
-	GetUserPointDBFunc      func(userID string) (int, error)
-	GetUserListPointsDBFunc func(userIDs []string) (map[string]int32, error)
+	GetUserPointDBFunc        func(ctx context.Context, userID string) (int, error)
+	GetUserListPointsDBFunc   func(ctx context.Context, userIDs []string) (map[string]int32, error)
+	AwardPointsDBFunc         func(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	DeductPointsDBFunc        func(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	SetPointsDBFunc           func(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	GetUserPointHistoryDBFunc func(ctx context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error)
+	GetLeaderboardDBFunc      func(ctx context.Context, limit int, departmentID string) ([]models.Points, error)
 }
 
-func (m *MockRepositoryDB) GetUserPointDB(userID string) (int, error) {
-	return m.GetUserPointDBFunc(userID)
+func (m *MockRepositoryDB) GetUserPointDB(ctx context.Context, userID string) (int, error) {
+	return m.GetUserPointDBFunc(ctx, userID)
+}
+func (m *MockRepositoryDB) GetUserListPointsDB(ctx context.Context, userIDs []string) (map[string]int32, error) {
+	return m.GetUserListPointsDBFunc(ctx, userIDs)
+}
+func (m *MockRepositoryDB) AwardPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	return m.AwardPointsDBFunc(ctx, userID, points, reason, actor)
+}
+func (m *MockRepositoryDB) DeductPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	return m.DeductPointsDBFunc(ctx, userID, points, reason, actor)
+}
+func (m *MockRepositoryDB) SetPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	return m.SetPointsDBFunc(ctx, userID, points, reason, actor)
+}
+func (m *MockRepositoryDB) GetUserPointHistoryDB(ctx context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error) {
+	return m.GetUserPointHistoryDBFunc(ctx, userID, limit, offset)
 }
-func (m *MockRepositoryDB) GetUserListPointsDB(userIDs []string) (map[string]int32, error) {
-	return m.GetUserListPointsDBFunc(userIDs)
+func (m *MockRepositoryDB) GetLeaderboardDB(ctx context.Context, limit int, departmentID string) ([]models.Points, error) {
+	return m.GetLeaderboardDBFunc(ctx, limit, departmentID)
 }
@@ -1,16 +1,45 @@
 package point
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/syedomair/backend-microservices/lib/logging"
+	"github.com/syedomair/backend-microservices/models"
 	"go.uber.org/zap"
 )
 
 type PointServiceInterface interface {
-	GetUserPoints(userID string) (int, error)
-	GetUserListPoints(userIDs []string) (map[string]int32, error)
+	GetUserPoints(ctx context.Context, userID string) (int, error)
+	GetUserListPoints(ctx context.Context, userIDs []string) (map[string]int32, error)
+	AwardPoints(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	DeductPoints(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	SetPoints(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	GetUserPointHistory(ctx context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error)
+	GetLeaderboard(ctx context.Context, limit int, departmentID string) ([]*models.LeaderboardEntry, error)
 }
 
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+var (
+	// ErrInvalidPoints is returned when a mutation is requested with an unusable amount.
+	ErrInvalidPoints = errors.New("invalid points amount")
+	// ErrMissingAudit is returned when a mutation does not say why or by whom it was made.
+	ErrMissingAudit = errors.New("reason and actor are required")
+	// ErrInvalidPagination is returned for a negative limit or offset.
+	ErrInvalidPagination = errors.New("limit and offset must not be negative")
+	// ErrInvalidLimit is returned for a negative leaderboard limit.
+	ErrInvalidLimit = errors.New("limit must not be negative")
+)
+
 type PointService struct {
 	repo   Repository
 	logger *zap.Logger
@@ -21,31 +50,194 @@ func NewPointService(repo Repository, logger *zap.Logger) *PointService {
 }
 
 // GetUserPoints
-func (p *PointService) GetUserPoints(userID string) (int, error) {
+func (p *PointService) GetUserPoints(ctx context.Context, userID string) (int, error) {
 	methodName := "GetUserPoints"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	points, err := p.repo.GetUserPointDB(userID)
+	points, err := p.repo.GetUserPointDB(ctx, userID)
 	if err != nil {
 		return 0, err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
 	return points, nil
 }
 
 // GetUserListPoints
-func (m *PointService) GetUserListPoints(userIDs []string) (map[string]int32, error) {
+func (m *PointService) GetUserListPoints(ctx context.Context, userIDs []string) (map[string]int32, error) {
 	methodName := "GetUserListPoints"
-	m.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, m.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	points, err := m.repo.GetUserListPointsDB(userIDs)
+	points, err := m.repo.GetUserListPointsDB(ctx, userIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	m.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
 	return points, nil
 }
+
+// AwardPoints adds points to the user's balance and returns the new balance.
+func (p *PointService) AwardPoints(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	methodName := "AwardPoints"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	if points <= 0 {
+		return 0, ErrInvalidPoints
+	}
+	reason, actor, err := validateAudit(reason, actor)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := p.repo.AwardPointsDB(ctx, userID, points, reason, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return balance, nil
+}
+
+// DeductPoints removes points from the user's balance and returns the new balance.
+func (p *PointService) DeductPoints(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	methodName := "DeductPoints"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	if points <= 0 {
+		return 0, ErrInvalidPoints
+	}
+	reason, actor, err := validateAudit(reason, actor)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := p.repo.DeductPointsDB(ctx, userID, points, reason, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return balance, nil
+}
+
+// SetPoints overwrites the user's balance and returns it.
+func (p *PointService) SetPoints(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	methodName := "SetPoints"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	if points < 0 {
+		return 0, ErrInvalidPoints
+	}
+	reason, actor, err := validateAudit(reason, actor)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := p.repo.SetPointsDB(ctx, userID, points, reason, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return balance, nil
+}
+
+// GetUserPointHistory returns a page of the user's ledger, newest first, with the total entry count.
+func (p *PointService) GetUserPointHistory(ctx context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error) {
+	methodName := "GetUserPointHistory"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	limit, err := normalizeHistoryPage(limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	transactions, total, err := p.repo.GetUserPointHistoryDB(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return transactions, total, nil
+}
+
+// GetLeaderboard returns the top balances, optionally restricted to one department.
+func (p *PointService) GetLeaderboard(ctx context.Context, limit int, departmentID string) ([]*models.LeaderboardEntry, error) {
+	methodName := "GetLeaderboard"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	limit, err := normalizeLeaderboardLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := p.repo.GetLeaderboardDB(ctx, limit, departmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return rankPoints(points), nil
+}
+
+// rankPoints assigns competition ranks to balances that are already sorted highest first.
+func rankPoints(points []models.Points) []*models.LeaderboardEntry {
+	entries := make([]*models.LeaderboardEntry, 0, len(points))
+	for i, point := range points {
+		rank := i + 1
+		if i > 0 && point.Points == points[i-1].Points {
+			rank = entries[i-1].Rank
+		}
+		entries = append(entries, &models.LeaderboardEntry{Rank: rank, UserID: point.UserID, Points: point.Points})
+	}
+	return entries
+}
+
+// normalizeLeaderboardLimit applies the default and maximum leaderboard size.
+func normalizeLeaderboardLimit(limit int) (int, error) {
+	if limit < 0 {
+		return 0, ErrInvalidLimit
+	}
+	if limit == 0 {
+		return defaultLeaderboardLimit, nil
+	}
+	if limit > maxLeaderboardLimit {
+		return maxLeaderboardLimit, nil
+	}
+	return limit, nil
+}
+
+// normalizeHistoryPage applies the default and maximum page size.
+func normalizeHistoryPage(limit, offset int) (int, error) {
+	if limit < 0 || offset < 0 {
+		return 0, ErrInvalidPagination
+	}
+	if limit == 0 {
+		return defaultHistoryLimit, nil
+	}
+	if limit > maxHistoryLimit {
+		return maxHistoryLimit, nil
+	}
+	return limit, nil
+}
+
+func validateAudit(reason, actor string) (string, string, error) {
+	reason = strings.TrimSpace(reason)
+	actor = strings.TrimSpace(actor)
+	if reason == "" || actor == "" {
+		return "", "", ErrMissingAudit
+	}
+	return reason, actor, nil
+}
@@ -1,11 +1,13 @@
 package point
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/syedomair/backend-microservices/models"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -14,14 +16,34 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) GetUserPointDB(userID string) (int, error) {
+func (m *MockRepository) GetUserPointDB(_ context.Context, userID string) (int, error) {
 	args := m.Called(userID)
 	return args.Int(0), args.Error(1)
 }
-func (m *MockRepository) GetUserListPointsDB(userIDs []string) (map[string]int32, error) {
+func (m *MockRepository) GetUserListPointsDB(_ context.Context, userIDs []string) (map[string]int32, error) {
 	args := m.Called(userIDs)
 	return args.Get(0).(map[string]int32), args.Error(1)
 }
+func (m *MockRepository) AwardPointsDB(_ context.Context, userID string, points int, reason, actor string) (int, error) {
+	args := m.Called(userID, points, reason, actor)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockRepository) DeductPointsDB(_ context.Context, userID string, points int, reason, actor string) (int, error) {
+	args := m.Called(userID, points, reason, actor)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockRepository) SetPointsDB(_ context.Context, userID string, points int, reason, actor string) (int, error) {
+	args := m.Called(userID, points, reason, actor)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockRepository) GetUserPointHistoryDB(_ context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error) {
+	args := m.Called(userID, limit, offset)
+	return args.Get(0).([]models.PointTransaction), args.Get(1).(int64), args.Error(2)
+}
+func (m *MockRepository) GetLeaderboardDB(_ context.Context, limit int, departmentID string) ([]models.Points, error) {
+	args := m.Called(limit, departmentID)
+	return args.Get(0).([]models.Points), args.Error(1)
+}
 
 func TestPointService_GetUserPoints(t *testing.T) {
 	t.Run("success - points retrieved", func(t *testing.T) {
@@ -31,7 +53,7 @@ func TestPointService_GetUserPoints(t *testing.T) {
 
 		repo.On("GetUserPointDB", "user123").Return(100, nil)
 
-		points, err := service.GetUserPoints("user123")
+		points, err := service.GetUserPoints(context.Background(), "user123")
 
 		assert.NoError(t, err)
 		assert.Equal(t, 100, points)
@@ -45,7 +67,7 @@ func TestPointService_GetUserPoints(t *testing.T) {
 
 		repo.On("GetUserPointDB", "user123").Return(0, errors.New("db error"))
 
-		points, err := service.GetUserPoints("user123")
+		points, err := service.GetUserPoints(context.Background(), "user123")
 
 		assert.Error(t, err)
 		assert.Equal(t, 0, points)
@@ -67,7 +89,7 @@ func TestPointService_GetUserListPoints(t *testing.T) {
 		mapUserPoints["3"] = int32(14)
 		repo.On("GetUserListPointsDB", userIDs).Return(mapUserPoints, nil)
 
-		points, err := service.GetUserListPoints(userIDs)
+		points, err := service.GetUserListPoints(context.Background(), userIDs)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 3, len(points))
@@ -87,7 +109,7 @@ func TestPointService_GetUserListPoints(t *testing.T) {
 		mapUserPoints["3"] = int32(14)
 		repo.On("GetUserListPointsDB", userIDs).Return(mapUserPoints, errors.New("db error"))
 
-		points, err := service.GetUserListPoints(userIDs)
+		points, err := service.GetUserListPoints(context.Background(), userIDs)
 
 		assert.Error(t, err)
 		assert.Equal(t, int32(0), points["2"])
@@ -95,3 +117,181 @@ func TestPointService_GetUserListPoints(t *testing.T) {
 		repo.AssertExpectations(t)
 	})
 }
+
+func TestPointService_AwardPoints(t *testing.T) {
+	t.Run("success - balance returned", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		repo.On("AwardPointsDB", "user123", 10, "bonus", "admin").Return(110, nil)
+
+		balance, err := service.AwardPoints(context.Background(), "user123", 10, "bonus", "admin")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 110, balance)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error - non positive amount", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		_, err := service.AwardPoints(context.Background(), "user123", 0, "bonus", "admin")
+
+		assert.ErrorIs(t, err, ErrInvalidPoints)
+		repo.AssertNotCalled(t, "AwardPointsDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPointService_DeductPoints(t *testing.T) {
+	t.Run("success - balance returned", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		repo.On("DeductPointsDB", "user123", 10, "redeem", "shop").Return(90, nil)
+
+		balance, err := service.DeductPoints(context.Background(), "user123", 10, "redeem", "shop")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 90, balance)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error - negative amount", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		_, err := service.DeductPoints(context.Background(), "user123", -5, "redeem", "shop")
+
+		assert.ErrorIs(t, err, ErrInvalidPoints)
+		repo.AssertNotCalled(t, "DeductPointsDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - insufficient points", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		repo.On("DeductPointsDB", "user123", 500, "redeem", "shop").Return(0, ErrInsufficientPoints)
+
+		_, err := service.DeductPoints(context.Background(), "user123", 500, "redeem", "shop")
+
+		assert.ErrorIs(t, err, ErrInsufficientPoints)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPointService_MissingAudit(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewPointService(repo, zaptest.NewLogger(t))
+
+	_, err := service.AwardPoints(context.Background(), "user123", 10, "  ", "admin")
+	assert.ErrorIs(t, err, ErrMissingAudit)
+
+	_, err = service.DeductPoints(context.Background(), "user123", 10, "redeem", "")
+	assert.ErrorIs(t, err, ErrMissingAudit)
+	repo.AssertNotCalled(t, "AwardPointsDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "DeductPointsDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPointService_SetPoints(t *testing.T) {
+	t.Run("success - zero is allowed", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		repo.On("SetPointsDB", "user123", 0, "reset", "admin").Return(0, nil)
+
+		balance, err := service.SetPoints(context.Background(), "user123", 0, "reset", "admin")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, balance)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error - negative amount", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		_, err := service.SetPoints(context.Background(), "user123", -1, "reset", "admin")
+
+		assert.ErrorIs(t, err, ErrInvalidPoints)
+		repo.AssertNotCalled(t, "SetPointsDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPointService_GetUserPointHistory(t *testing.T) {
+	t.Run("success - limit is capped", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		transactions := []models.PointTransaction{{ID: "t1", UserID: "user123", Delta: 5, BalanceAfter: 5}}
+		repo.On("GetUserPointHistoryDB", "user123", maxHistoryLimit, 10).Return(transactions, int64(11), nil)
+
+		history, total, err := service.GetUserPointHistory(context.Background(), "user123", 1000, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), total)
+		assert.Len(t, history, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error - negative offset", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		_, _, err := service.GetUserPointHistory(context.Background(), "user123", 10, -1)
+
+		assert.ErrorIs(t, err, ErrInvalidPagination)
+		repo.AssertNotCalled(t, "GetUserPointHistoryDB", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPointService_GetLeaderboard(t *testing.T) {
+	t.Run("success - ties share a rank", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		points := []models.Points{
+			{UserID: "u1", Points: 90},
+			{UserID: "u2", Points: 70},
+			{UserID: "u3", Points: 70},
+			{UserID: "u4", Points: 50},
+			{UserID: "u5", Points: 50},
+			{UserID: "u6", Points: 10},
+		}
+		repo.On("GetLeaderboardDB", defaultLeaderboardLimit, "").Return(points, nil)
+
+		entries, err := service.GetLeaderboard(context.Background(), 0, "")
+
+		assert.NoError(t, err)
+		ranks := []int{}
+		for _, entry := range entries {
+			ranks = append(ranks, entry.Rank)
+		}
+		assert.Equal(t, []int{1, 2, 2, 4, 4, 6}, ranks)
+		assert.Equal(t, "u3", entries[2].UserID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success - limit is capped", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		repo.On("GetLeaderboardDB", maxLeaderboardLimit, "dep1").Return([]models.Points{}, nil)
+
+		entries, err := service.GetLeaderboard(context.Background(), 5000, "dep1")
+
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("error - negative limit", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewPointService(repo, zaptest.NewLogger(t))
+
+		_, err := service.GetLeaderboard(context.Background(), -1, "")
+
+		assert.ErrorIs(t, err, ErrInvalidLimit)
+		repo.AssertNotCalled(t, "GetLeaderboardDB", mock.Anything, mock.Anything)
+	})
+}
@@ -1,7 +1,28 @@
 package point
 
+import (
+	"context"
+	"errors"
+
+	"github.com/syedomair/backend-microservices/models"
+)
+
+var (
+	// ErrUserNotFound is returned when mutating the points of a user that does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInsufficientPoints is returned when a mutation would leave a negative balance.
+	ErrInsufficientPoints = errors.New("insufficient points: balance cannot become negative")
+	// ErrBalanceOverflow is returned when a mutation would exceed the maximum balance.
+	ErrBalanceOverflow = errors.New("balance exceeds the maximum allowed value")
+)
+
 // Repository interface
 type Repository interface {
-	GetUserPointDB(userID string) (int, error)
-	GetUserListPointsDB(userIDs []string) (map[string]int32, error)
+	GetUserPointDB(ctx context.Context, userID string) (int, error)
+	GetUserListPointsDB(ctx context.Context, userIDs []string) (map[string]int32, error)
+	AwardPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	DeductPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	SetPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error)
+	GetUserPointHistoryDB(ctx context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error)
+	GetLeaderboardDB(ctx context.Context, limit int, departmentID string) ([]models.Points, error)
 }
@@ -1,11 +1,17 @@
 package point
 
 import (
+	"context"
+	"errors"
+	"math"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/syedomair/backend-microservices/lib/logging"
 	"github.com/syedomair/backend-microservices/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type dbRepo struct {
@@ -19,33 +25,35 @@ func NewDBRepository(c *gorm.DB, logger *zap.Logger) Repository {
 }
 
 // GetUserPointDB Public
-func (p *dbRepo) GetUserPointDB(userID string) (int, error) {
+func (p *dbRepo) GetUserPointDB(ctx context.Context, userID string) (int, error) {
 	methodName := "GetUserPointDB"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
 	points := models.Points{}
-	if err := p.client.
+	if err := p.client.WithContext(ctx).
 		Where("user_id = ?", userID).
 		Find(&points).Error; err != nil {
-		return 0, nil
+		return 0, err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
 	return points.Points, nil
 }
 
 // GetUserListPointsDB Public
-func (p *dbRepo) GetUserListPointsDB(userIDs []string) (map[string]int32, error) {
+func (p *dbRepo) GetUserListPointsDB(ctx context.Context, userIDs []string) (map[string]int32, error) {
 	methodName := "GetUserListPointsDB"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
 	points := []models.Points{}
-	if err := p.client.
+	if err := p.client.WithContext(ctx).
 		Where("user_id in (?)", userIDs).
 		Find(&points).Error; err != nil {
-		return nil, nil
+		return nil, err
 	}
 
 	mapUserPoints := make(map[string]int32)
@@ -53,6 +61,196 @@ func (p *dbRepo) GetUserListPointsDB(userIDs []string) (map[string]int32, error)
 		mapUserPoints[userPoint.UserID] = int32(userPoint.Points)
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
 	return mapUserPoints, nil
 }
+
+// AwardPointsDB Public
+func (p *dbRepo) AwardPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	methodName := "AwardPointsDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	balance, err := p.updatePoints(ctx, userID, reason, actor, func(current int) (int, error) {
+		return current + points, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return balance, nil
+}
+
+// DeductPointsDB Public
+func (p *dbRepo) DeductPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	methodName := "DeductPointsDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	balance, err := p.updatePoints(ctx, userID, reason, actor, func(current int) (int, error) {
+		if current < points {
+			return 0, ErrInsufficientPoints
+		}
+		return current - points, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return balance, nil
+}
+
+// SetPointsDB Public
+func (p *dbRepo) SetPointsDB(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+	methodName := "SetPointsDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	balance, err := p.updatePoints(ctx, userID, reason, actor, func(int) (int, error) {
+		return points, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return balance, nil
+}
+
+// GetUserPointHistoryDB Public
+func (p *dbRepo) GetUserPointHistoryDB(ctx context.Context, userID string, limit, offset int) ([]models.PointTransaction, int64, error) {
+	methodName := "GetUserPointHistoryDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	count := int64(0)
+	if err := p.client.WithContext(ctx).
+		Model(&models.PointTransaction{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	transactions := []models.PointTransaction{}
+	if err := p.client.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Order("id desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&transactions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return transactions, count, nil
+}
+
+// GetLeaderboardDB Public
+// Balances are returned highest first; ties are ordered by user id so the result is stable.
+func (p *dbRepo) GetLeaderboardDB(ctx context.Context, limit int, departmentID string) ([]models.Points, error) {
+	methodName := "GetLeaderboardDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	query := p.client.WithContext(ctx).
+		Model(&models.Points{}).
+		Select("points.id, points.user_id, COALESCE(points.points, 0) AS points").
+		Where("points.user_id IS NOT NULL")
+	if departmentID != "" {
+		query = query.
+			Joins("JOIN ? u ON u.id = points.user_id", clause.Table{Name: models.User{}.TableName()}).
+			Where("u.department_id = ?", departmentID)
+	}
+
+	points := []models.Points{}
+	if err := query.
+		Order("points desc").
+		Order("points.user_id asc").
+		Limit(limit).
+		Find(&points).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return points, nil
+}
+
+// updatePoints computes and stores a user's new balance inside a transaction.
+// The user row is locked first so concurrent mutations for the same user are serialized,
+// including the very first one that has to create the points row. Every change is
+// appended to the ledger in the same transaction as the snapshot update.
+func (p *dbRepo) updatePoints(ctx context.Context, userID, reason, actor string, apply func(current int) (int, error)) (int, error) {
+	balance := 0
+	err := p.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user := struct{ ID string }{}
+		if err := tx.Table("user").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", userID).
+			Take(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		row := models.Points{}
+		err := tx.Where("user_id = ?", userID).Take(&row).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		exists := err == nil
+
+		previous := row.Points
+		newBalance, err := apply(previous)
+		if err != nil {
+			return err
+		}
+		if newBalance < 0 {
+			return ErrInsufficientPoints
+		}
+		if newBalance > math.MaxInt32 {
+			return ErrBalanceOverflow
+		}
+
+		if exists {
+			if err := tx.Model(&models.Points{}).
+				Where("id = ?", row.ID).
+				Update("points", newBalance).Error; err != nil {
+				return err
+			}
+		} else {
+			row = models.Points{ID: uuid.New().String(), UserID: userID, Points: newBalance}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		entry := models.PointTransaction{
+			ID:           uuid.New().String(),
+			UserID:       userID,
+			Delta:        newBalance - previous,
+			BalanceAfter: newBalance,
+			Reason:       reason,
+			Actor:        actor,
+			CreatedAt:    time.Now().UTC(),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		balance = newBalance
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
@@ -1,6 +1,7 @@
 package point
 
 import (
+	"context"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -10,7 +11,7 @@ import (
 func TestGetUserListPointsDB_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepositoryDB{
-		GetUserListPointsDBFunc: func(userIDs []string) (map[string]int32, error) {
+		GetUserListPointsDBFunc: func(ctx context.Context, userIDs []string) (map[string]int32, error) {
 			mapUserPoints := make(map[string]int32)
 			mapUserPoints["1"] = int32(12)
 			mapUserPoints["2"] = int32(13)
@@ -21,7 +22,7 @@ func TestGetUserListPointsDB_Success(t *testing.T) {
 
 	userIDs := []string{"1", "2", "3"}
 	// Act
-	points, err := mockRepo.GetUserListPointsDB(userIDs)
+	points, err := mockRepo.GetUserListPointsDB(context.Background(), userIDs)
 
 	// Assert
 	assert.NoError(t, err)
@@ -32,12 +33,12 @@ func TestGetUserListPointsDB_Success(t *testing.T) {
 func TestGetUserPointDB_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepositoryDB{
-		GetUserPointDBFunc: func(userID string) (int, error) {
+		GetUserPointDBFunc: func(ctx context.Context, userID string) (int, error) {
 			return 1, nil
 		},
 	}
 	// Act
-	point, err := mockRepo.GetUserPointDB("10")
+	point, err := mockRepo.GetUserPointDB(context.Background(), "10")
 
 	// Assert
 	assert.NoError(t, err)
@@ -47,15 +48,47 @@ func TestGetUserPointDB_Success(t *testing.T) {
 func TestGetUserPointDB_Error(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepositoryDB{
-		GetUserPointDBFunc: func(userID string) (int, error) {
+		GetUserPointDBFunc: func(ctx context.Context, userID string) (int, error) {
 			return 0, errors.New("database error")
 		},
 	}
 
 	// Act
-	point, err := mockRepo.GetUserPointDB("10")
+	point, err := mockRepo.GetUserPointDB(context.Background(), "10")
 
 	// Assert
 	assert.Error(t, err)
 	assert.Equal(t, 0, point)
 }
+
+func TestDeductPointsDB_Insufficient(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepositoryDB{
+		DeductPointsDBFunc: func(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+			return 0, ErrInsufficientPoints
+		},
+	}
+
+	// Act
+	balance, err := mockRepo.DeductPointsDB(context.Background(), "10", 50, "redeem", "shop")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInsufficientPoints)
+	assert.Equal(t, 0, balance)
+}
+
+func TestAwardPointsDB_Success(t *testing.T) {
+	// Arrange
+	mockRepo := &MockRepositoryDB{
+		AwardPointsDBFunc: func(ctx context.Context, userID string, points int, reason, actor string) (int, error) {
+			return 10 + points, nil
+		},
+	}
+
+	// Act
+	balance, err := mockRepo.AwardPointsDB(context.Background(), "10", 5, "bonus", "admin")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 15, balance)
+}
@@ -0,0 +1,101 @@
+package point
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syedomair/backend-microservices/lib/testdb"
+	"github.com/syedomair/backend-microservices/models"
+	"go.uber.org/zap/zaptest"
+	"gorm.io/gorm"
+)
+
+func userID(t *testing.T, db *gorm.DB, email string) string {
+	user := models.User{}
+	require.NoError(t, db.Where("email = ?", email).Take(&user).Error)
+	return user.ID
+}
+
+func TestSQLite_PointMutations(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+	alice := userID(t, db, "alice.johnson@example.com")
+
+	balance, err := repo.AwardPointsDB(context.Background(), alice, 7, "bonus", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 30, balance)
+
+	balance, err = repo.DeductPointsDB(context.Background(), alice, 10, "redeem", "shop")
+	require.NoError(t, err)
+	assert.Equal(t, 20, balance)
+
+	_, err = repo.DeductPointsDB(context.Background(), alice, 21, "redeem", "shop")
+	assert.ErrorIs(t, err, ErrInsufficientPoints)
+
+	balance, err = repo.SetPointsDB(context.Background(), alice, 5, "correction", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 5, balance)
+
+	points, err := repo.GetUserPointDB(context.Background(), alice)
+	require.NoError(t, err)
+	assert.Equal(t, 5, points)
+
+	history, total, err := repo.GetUserPointHistoryDB(context.Background(), alice, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, -15, history[0].Delta)
+	assert.Equal(t, "correction", history[0].Reason)
+	assert.Equal(t, "opening_balance", history[3].Reason)
+
+	_, err = repo.AwardPointsDB(context.Background(), "00000000-0000-4000-8000-000000000000", 1, "bonus", "admin")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestSQLite_FirstMutationCreatesPointsRow(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+	alice := userID(t, db, "alice.johnson@example.com")
+	require.NoError(t, db.Where("user_id = ?", alice).Delete(&models.Points{}).Error)
+
+	balance, err := repo.AwardPointsDB(context.Background(), alice, 3, "bonus", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 3, balance)
+}
+
+func TestSQLite_GetLeaderboardDB(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+
+	points, err := repo.GetLeaderboardDB(context.Background(), 3, "")
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, []int{93, 83, 73}, []int{points[0].Points, points[1].Points, points[2].Points})
+
+	department := models.Department{}
+	require.NoError(t, db.Where("name = ?", "Human Resources").Take(&department).Error)
+	points, err = repo.GetLeaderboardDB(context.Background(), 10, department.ID)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, 43, points[0].Points)
+}
+
+func TestSQLite_CanceledContext(t *testing.T) {
+	db := testdb.New(t)
+	repo := NewDBRepository(db, zaptest.NewLogger(t))
+	alice := userID(t, db, "alice.johnson@example.com")
+	before, err := repo.GetUserPointDB(context.Background(), alice)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.GetUserPointDB(ctx, alice)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.AwardPointsDB(ctx, alice, 1, "bonus", "admin")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	points, err := repo.GetUserPointDB(context.Background(), alice)
+	require.NoError(t, err)
+	assert.Equal(t, before, points, "the canceled award must not be applied")
+}
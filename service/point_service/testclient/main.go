@@ -33,6 +33,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("could not get Points: %v", err)
 	}
+	r2, err := c.AwardPoints(ctx, &pb.PointMutationRequest{UserId: "520349ca-a915-487d-ba21-c9740dd08aa7", Points: 10, Reason: "testclient", Actor: "testclient"})
+	if err != nil {
+		log.Fatalf("could not award Points: %v", err)
+	}
 	log.Printf("User Point: %s", r.GetUserPoint())
 	log.Printf("User Point: %v", r1.GetUserPoints())
+	log.Printf("User Balance: %d", r2.GetBalance())
 }
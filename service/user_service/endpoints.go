@@ -1,25 +1,91 @@
 package main
 
 import (
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/breaker"
 	"github.com/syedomair/backend-microservices/lib/container"
 	"github.com/syedomair/backend-microservices/lib/router"
 	"github.com/syedomair/backend-microservices/service/user_service/user"
 )
 
+// defaultRateLimit applies to every endpoint without a RateLimit of its own.
+var defaultRateLimit = router.RateLimit{Rate: 10, Burst: 50}
+
 func EndPointConf(c container.Container) []router.EndPoint {
 
 	userController := user.Controller{
 		Logger:                     c.Logger(),
 		Repo:                       user.NewDBRepository(c.Db(), c.Logger()),
 		PointServiceConnectionPool: c.PointServicePool(),
+		PointServiceBreaker:        breaker.New("point_service", breaker.Settings{}),
+		Cursors:                    c.CursorCodec(),
 	}
 
+	// Listing users runs a statistics query next to the list, so one client must not be able to saturate the database pool.
+	listRateLimit := &router.RateLimit{Rate: 2, Burst: 10}
+	// Support staff search many times a day, but each search scans name and email.
+	searchRateLimit := &router.RateLimit{Rate: 5, Burst: 20}
+
 	return []router.EndPoint{
 		{
 			Name:        "GetAllUser",
 			Method:      router.Get,
 			Pattern:     "/users",
 			HandlerFunc: userController.GetAllUsers,
+			Permissions: []string{auth.PermUsersRead},
+			RateLimit:   listRateLimit,
+		},
+		{
+			Name:        "GetUserStats",
+			Method:      router.Get,
+			Pattern:     "/users/stats",
+			HandlerFunc: userController.GetUserStats,
+			Permissions: []string{auth.PermUsersRead},
+			RateLimit:   listRateLimit,
+		},
+		{
+			Name:        "GetLeaderboard",
+			Method:      router.Get,
+			Pattern:     "/users/leaderboard",
+			HandlerFunc: userController.GetLeaderboard,
+			Permissions: []string{auth.PermUsersRead},
+			RateLimit:   listRateLimit,
+		},
+		{
+			Name:        "SearchUsers",
+			Method:      router.Get,
+			Pattern:     "/users/search",
+			HandlerFunc: userController.SearchUsers,
+			Permissions: []string{auth.PermUsersRead},
+			RateLimit:   searchRateLimit,
+		},
+		{
+			Name:        "CreateUser",
+			Method:      router.Post,
+			Pattern:     "/users",
+			HandlerFunc: userController.CreateUser,
+			Permissions: []string{auth.PermUsersWrite},
+		},
+		{
+			Name:        "GetUser",
+			Method:      router.Get,
+			Pattern:     "/users/{id}",
+			HandlerFunc: userController.GetUser,
+			Permissions: []string{auth.PermUsersRead},
+		},
+		{
+			Name:        "UpdateUser",
+			Method:      router.Patch,
+			Pattern:     "/users/{id}",
+			HandlerFunc: userController.UpdateUser,
+			Permissions: []string{auth.PermUsersWrite},
+		},
+		{
+			Name:        "DeleteUser",
+			Method:      router.Delete,
+			Pattern:     "/users/{id}",
+			HandlerFunc: userController.DeleteUser,
+			Permissions: []string{auth.PermUsersWrite},
 		},
 	}
 }
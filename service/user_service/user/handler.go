@@ -1,15 +1,21 @@
 package user
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/breaker"
 	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/logging"
 	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/lib/response"
+	"github.com/syedomair/backend-microservices/lib/router"
 	"github.com/syedomair/backend-microservices/models"
 	pb "github.com/syedomair/backend-microservices/proto/v1/point"
 
@@ -20,68 +26,372 @@ type Controller struct {
 	Logger                     *zap.Logger
 	Repo                       Repository
 	PointServiceConnectionPool container.ConnectionPoolInterface
+	PointServiceBreaker        *breaker.Breaker
+	Cursors                    *request.CursorCodec
 }
 
 // GetAllUsers retrieves all users with additional statistics.
 func (c *Controller) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	methodName := "GetAllUsers"
-	c.Logger.Debug("method start", zap.String("method", methodName))
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
 	start := time.Now()
 
-	queryParam, err := request.ValidateQueryString(r, "1000", "0", "name", "asc")
+	queryParam, err := request.ValidateQueryString(r, "1000", "0", "name", "asc", sortColumns(r), c.Cursors)
 	if err != nil {
-		c.handleError(methodName, w, err, http.StatusBadRequest)
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	if queryParam.Filter, err = request.ValidateFilter(r, filterFields(r)); err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	statsScope, err := validateStatsScope(r)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	userStatistics, err := c.GetUserStatistics(r.Context(), queryParam.FetchLimit(), queryParam.Page, queryParam.OrderBy, queryParam.Keyset, queryParam.Filter, statsScope)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromServiceError(err, http.StatusBadRequest))
 		return
 	}
 
-	userStatistics, err := c.GetUserStatistics(queryParam.Limit, queryParam.Page, queryParam.OrderBy, queryParam.Sort)
+	count, _ := strconv.Atoi(userStatistics.Count)
+	userList, cursors, err := request.Paginate(queryParam, userStatistics.UserList, count)
 	if err != nil {
-		c.handleError(methodName, w, err, http.StatusBadRequest)
+		c.handleError(methodName, w, r, err, http.StatusInternalServerError)
 		return
 	}
 
+	age, salary := userStatistics.Aggregates.Age, userStatistics.Aggregates.Salary
 	responseUserObj := models.ResponseUser{
-		HighAge:    strconv.Itoa(userStatistics.UserHighAge),
-		LowAge:     strconv.Itoa(userStatistics.UserLowAge),
-		AvgAge:     fmt.Sprintf("%.2f", userStatistics.UserAvgAge),
-		HighSalary: fmt.Sprintf("%.2f", userStatistics.UserHighSalary),
-		LowSalary:  fmt.Sprintf("%.2f", userStatistics.UserLowSalary),
-		AvgSalary:  fmt.Sprintf("%.2f", userStatistics.UserAvgSalary),
-		Count:      userStatistics.Count,
-		List:       userStatistics.UserList,
+		HighAge:      strconv.Itoa(int(age.Max)),
+		LowAge:       strconv.Itoa(int(age.Min)),
+		AvgAge:       fmt.Sprintf("%.2f", age.Avg),
+		HighSalary:   fmt.Sprintf("%.2f", salary.Max),
+		LowSalary:    fmt.Sprintf("%.2f", salary.Min),
+		AvgSalary:    fmt.Sprintf("%.2f", salary.Avg),
+		Count:        userStatistics.Count,
+		MedianAge:    fmt.Sprintf("%.2f", age.Median),
+		P90Age:       fmt.Sprintf("%.2f", age.P90),
+		StdDevAge:    fmt.Sprintf("%.2f", age.StdDev),
+		MedianSalary: fmt.Sprintf("%.2f", salary.Median),
+		P90Salary:    fmt.Sprintf("%.2f", salary.P90),
+		StdDevSalary: fmt.Sprintf("%.2f", salary.StdDev),
+		UserCount:    userStatistics.Aggregates.UserCount,
+		AgeCount:     age.Count,
+		SalaryCount:  salary.Count,
+		StatsScope:   statsScope,
+		List:         userList,
+		Degraded:     userStatistics.Degraded,
+		NextCursor:   cursors.Next,
+		PrevCursor:   cursors.Prev,
+	}
+	if !router.HasPermission(r.Context(), auth.PermUsersReadSalary) {
+		responseUserObj.RedactSalaries()
 	}
 
 	var responseObj map[string]interface{}
 	err = mapstructure.Decode(responseUserObj, &responseObj)
 	if err != nil {
-		c.handleError(methodName, w, err, http.StatusBadRequest)
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	c.Logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
 	response.SuccessResponseHelper(w, responseObj, http.StatusOK)
 }
 
 // GetUserStatistics
-func (c *Controller) GetUserStatistics(limit, offset int, orderBy, sort string) (*models.UserStatistics, error) {
+func (c *Controller) GetUserStatistics(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter, statsScope string) (*models.UserStatistics, error) {
 	methodName := "GetUserStatistics"
-	c.Logger.Debug("method start", zap.String("method", methodName))
+	logger := logging.FromContext(ctx, c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
 	start := time.Now()
 
 	var pointServerClient pb.PointServerClient
-	userService := NewUserService(c.Repo, c.Logger, pointServerClient, c.PointServiceConnectionPool)
+	userService := NewUserService(c.Repo, c.Logger, pointServerClient, c.PointServiceConnectionPool, c.PointServiceBreaker)
 
-	userStatistics, err := userService.GetAllUserStatistics(limit, offset, orderBy, sort)
+	userStatistics, err := userService.GetAllUserStatistics(ctx, limit, offset, orderBy, keyset, filter, statsScope)
 	if err != nil {
 		return nil, err
 	}
 
-	c.Logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
 	return userStatistics, nil
 }
 
+// GetUserStats returns the age and salary statistics of the users matching 'filter', and of each
+// department when 'group_by' is department.
+func (c *Controller) GetUserStats(w http.ResponseWriter, r *http.Request) {
+	methodName := "GetUserStats"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	filter, err := request.ValidateFilter(r, filterFields(r))
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	groupBy, err := validateGroupBy(r)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var pointServerClient pb.PointServerClient
+	userService := NewUserService(c.Repo, c.Logger, pointServerClient, c.PointServiceConnectionPool, c.PointServiceBreaker)
+
+	aggregates, departments, err := userService.GetUserAggregates(r.Context(), filter, groupBy == GroupByDepartment)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	responseObj := models.NewResponseUserStats(aggregates, departments)
+	if !router.HasPermission(r.Context(), auth.PermUsersReadSalary) {
+		responseObj.RedactSalaries()
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, responseObj, http.StatusOK)
+}
+
+// GetLeaderboard returns users ranked by points, optionally within one department.
+func (c *Controller) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	methodName := "GetLeaderboard"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	limit, departmentID, err := validateLeaderboardQuery(r)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var pointServerClient pb.PointServerClient
+	userService := NewUserService(c.Repo, c.Logger, pointServerClient, c.PointServiceConnectionPool, c.PointServiceBreaker)
+
+	entries, err := userService.GetLeaderboard(r.Context(), limit, departmentID)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromServiceError(err, http.StatusInternalServerError))
+		return
+	}
+
+	responseObj := models.ResponseLeaderboard{
+		DepartmentID: departmentID,
+		Limit:        limit,
+		List:         entries,
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, responseObj, http.StatusOK)
+}
+
+// SearchUsers returns the users whose name or email matches 'q', most relevant first.
+func (c *Controller) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	methodName := "SearchUsers"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	term, err := request.ValidateSearchTerm(r)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+	// Relevance comes first in the ordering, so results are paged by offset only.
+	queryParam, err := request.ValidateQueryString(r, "20", "0", "name", "asc", sortColumns(r), nil)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	users, count, err := c.Repo.SearchUserDB(r.Context(), term, queryParam.Limit, queryParam.Page, queryParam.OrderBy)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusInternalServerError)
+		return
+	}
+	var list interface{} = users
+	if !router.HasPermission(r.Context(), auth.PermUsersReadSalary) {
+		list = models.RedactSalaries(users)
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseList(w, list, strconv.Itoa(queryParam.Page), strconv.Itoa(queryParam.Limit), count, "", "")
+}
+
+// CreateUser creates a new user.
+func (c *Controller) CreateUser(w http.ResponseWriter, r *http.Request) {
+	methodName := "CreateUser"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	var input models.UserInput
+	if err := request.DecodeJSONBody(r, &input); err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := newUserFromInput(input)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err = c.Repo.CreateUserDB(r.Context(), user)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, userResponse(r, user), http.StatusCreated)
+}
+
+// GetUser retrieves a single user by ID.
+func (c *Controller) GetUser(w http.ResponseWriter, r *http.Request) {
+	methodName := "GetUser"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	userID, err := request.ValidatePathUUID(r, "id")
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.Repo.GetUserDB(r.Context(), userID)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, userResponse(r, user), http.StatusOK)
+}
+
+// UpdateUser applies a partial update to a user.
+func (c *Controller) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	methodName := "UpdateUser"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	userID, err := request.ValidatePathUUID(r, "id")
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var input models.UserInput
+	if err := request.DecodeJSONBody(r, &input); err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	fields, err := updateFieldsFromInput(input)
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.Repo.UpdateUserDB(r.Context(), userID, fields)
+	if err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, userResponse(r, user), http.StatusOK)
+}
+
+// DeleteUser removes a user by ID.
+func (c *Controller) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	methodName := "DeleteUser"
+	logger := logging.FromContext(r.Context(), c.Logger)
+	logger.Debug("method start", zap.String("method", methodName))
+	start := time.Now()
+
+	userID, err := request.ValidatePathUUID(r, "id")
+	if err != nil {
+		c.handleError(methodName, w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.Repo.DeleteUserDB(r.Context(), userID); err != nil {
+		c.handleError(methodName, w, r, err, statusFromError(err))
+		return
+	}
+
+	logger.Debug("method end", zap.String("method", methodName), zap.Duration("duration", time.Since(start)))
+	response.SuccessResponseHelper(w, map[string]string{"id": userID}, http.StatusOK)
+}
+
+// sortColumns returns the columns the caller may sort users by. Sorting by salary would reveal
+// salaries, so it needs auth.PermUsersReadSalary. A null age or salary sorts as the 0 it is listed
+// with. department_id is left out because users list a null one as null, which no cursor can hold.
+func sortColumns(r *http.Request) []request.SortColumn {
+	columns := append(request.NotNull("id", "name", "email"), request.SortColumn{Name: "age", NullAs: "0"})
+	if router.HasPermission(r.Context(), auth.PermUsersReadSalary) {
+		columns = append(columns, request.SortColumn{Name: "salary", NullAs: "0"})
+	}
+	return columns
+}
+
+// filterFields are the columns users may be filtered on. Like sorting, filtering by salary would
+// reveal it, so it needs auth.PermUsersReadSalary.
+func filterFields(r *http.Request) request.FilterFields {
+	fields := request.FilterFields{
+		"id":            {Type: request.UUID, Operators: []string{request.Eq, request.Ne, request.In}},
+		"department_id": {Type: request.UUID, Operators: []string{request.Eq, request.Ne, request.In}},
+		"name":          {Type: request.String, Operators: []string{request.Eq, request.Ne, request.In, request.Contains}},
+		"email":         {Type: request.String, Operators: []string{request.Eq, request.Ne, request.In, request.Contains}},
+		"age":           {Type: request.Int, Operators: request.Comparisons},
+	}
+	if router.HasPermission(r.Context(), auth.PermUsersReadSalary) {
+		fields["salary"] = request.FilterField{Type: request.Float, Operators: request.Comparisons}
+	}
+	return fields
+}
+
+// userResponse hides the salary of user from callers without auth.PermUsersReadSalary.
+func userResponse(r *http.Request, user *models.User) interface{} {
+	if router.HasPermission(r.Context(), auth.PermUsersReadSalary) {
+		return user
+	}
+	return &models.RedactedUser{User: user}
+}
+
+// statusFromError maps repository errors to HTTP status codes.
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrDuplicateEmail):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidDepartment):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // handleError
-func (c *Controller) handleError(methodName string, w http.ResponseWriter, err error, statusCode int) {
-	c.Logger.Error("method failed", zap.String("method", methodName), zap.Error(err))
+func (c *Controller) handleError(methodName string, w http.ResponseWriter, r *http.Request, err error, statusCode int) {
+	logging.FromContext(r.Context(), c.Logger).Error("method failed", zap.String("method", methodName), zap.Error(err))
 	response.ErrorResponseHelper(methodName, w, err.Error(), statusCode)
 }
+
+// statusFromServiceError reports an exhausted point service pool or an open breaker as 503, so clients know to retry.
+func statusFromServiceError(err error, fallback int) int {
+	if errors.Is(err, container.ErrPoolExhausted) || errors.Is(err, breaker.ErrOpen) {
+		return http.StatusServiceUnavailable
+	}
+	return fallback
+}
@@ -1,13 +1,22 @@
 package user
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/container"
 	"github.com/syedomair/backend-microservices/lib/mockgrpc"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/lib/router"
 	"github.com/syedomair/backend-microservices/models"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -17,15 +26,15 @@ func TestGetAllUsers_Success(t *testing.T) {
 	// Arrange
 	logger, _ := zap.NewDevelopment()
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return []*models.User{{ID: "1", Name: "John Doe", Age: 30, Salary: 50000.0}}, "1", nil
 		},
-		GetUserHighAgeFunc:    func() (int, error) { return 40, nil },
-		GetUserLowAgeFunc:     func() (int, error) { return 20, nil },
-		GetUserAvgAgeFunc:     func() (float64, error) { return 30.0, nil },
-		GetUserHighSalaryFunc: func() (float64, error) { return 100000.0, nil },
-		GetUserLowSalaryFunc:  func() (float64, error) { return 30000.0, nil },
-		GetUserAvgSalaryFunc:  func() (float64, error) { return 65000.0, nil },
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return &models.UserAggregates{
+				Age:    models.FieldStatistics{Min: 20, Max: 40, Avg: 30},
+				Salary: models.FieldStatistics{Min: 30000, Max: 100000, Avg: 65000},
+			}, nil
+		},
 	}
 
 	_, conn, _ := mockgrpc.SetupGRPCServer(t) // Use the helper function
@@ -85,26 +94,14 @@ func TestGetAllUsers_RepositoryError(t *testing.T) {
 	// Arrange
 	logger, _ := zap.NewDevelopment()
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return nil, "", errors.New("repository error")
 		},
-		GetUserHighAgeFunc: func() (int, error) {
-			return 40, nil
-		},
-		GetUserLowAgeFunc: func() (int, error) {
-			return 20, nil
-		},
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 30.5, nil
-		},
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 50000.0, nil
-		},
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 150000.0, nil
-		},
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 100000.0, nil
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return &models.UserAggregates{
+				Age:    models.FieldStatistics{Min: 20, Max: 40, Avg: 30.5},
+				Salary: models.FieldStatistics{Min: 50000, Max: 150000, Avg: 100000},
+			}, nil
 		},
 	}
 
@@ -129,26 +126,11 @@ func TestGetAllUsers_StatisticsError(t *testing.T) {
 	// Arrange
 	logger, _ := zap.NewDevelopment()
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return []*models.User{{ID: "1", Name: "John Doe", Age: 30, Salary: 50000.0}}, "1", nil
 		},
-		GetUserHighAgeFunc: func() (int, error) {
-			return 0, errors.New("statistics error")
-		},
-		GetUserLowAgeFunc: func() (int, error) {
-			return 20, nil
-		},
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 30.5, nil
-		},
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 50000.0, nil
-		},
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 150000.0, nil
-		},
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 100000.0, nil
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return nil, errors.New("statistics error")
 		},
 	}
 
@@ -181,3 +163,644 @@ func TestGetAllUsers_StatisticsError(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+const testUserID = "520349ca-a915-487d-ba21-c9740dd08aa7"
+
+// newRequestWithID builds a request carrying the chi {id} URL parameter.
+func newRequestWithID(t *testing.T, method, id, body string) *http.Request {
+	req, err := http.NewRequest(method, "/users/"+id, strings.NewReader(body))
+	assert.NoError(t, err)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCreateUser_Success(t *testing.T) {
+	// Arrange
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		CreateUserDBFunc: func(ctx context.Context, user *models.User) (*models.User, error) {
+			user.ID = testUserID
+			return user, nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"Jane Doe","email":"Jane@Example.com","age":31,"salary":72000}`))
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	// Act
+	controller.CreateUser(rr, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, testUserID, data["id"])
+	assert.Equal(t, "jane@example.com", data["email"])
+	assert.Nil(t, data["department_id"])
+}
+
+func TestCreateUser_ValidationError(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{Logger: logger, Repo: &MockRepository{}}
+
+	bodies := []string{
+		``,
+		`{"email":"jane@example.com"}`,
+		`{"name":"Jane","email":"not-an-email"}`,
+		`{"name":"Jane","email":"jane@example.com","age":-1}`,
+		`{"name":"Jane","email":"jane@example.com","department_id":"hr"}`,
+		`{"name":"Jane","email":"jane@example.com","role":"admin"}`,
+	}
+	for _, body := range bodies {
+		req, err := http.NewRequest("POST", "/users", strings.NewReader(body))
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		controller.CreateUser(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
+
+func TestCreateUser_DuplicateEmail(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		CreateUserDBFunc: func(ctx context.Context, user *models.User) (*models.User, error) {
+			return nil, ErrDuplicateEmail
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"Jane Doe","email":"jane@example.com"}`))
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	controller.CreateUser(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestGetUser_Success(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		GetUserDBFunc: func(ctx context.Context, userID string) (*models.User, error) {
+			return &models.User{ID: userID, Name: "John Doe"}, nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.GetUser(rr, newRequestWithID(t, "GET", testUserID, ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestGetUser_NotFound(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		GetUserDBFunc: func(ctx context.Context, userID string) (*models.User, error) {
+			return nil, ErrUserNotFound
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.GetUser(rr, newRequestWithID(t, "GET", testUserID, ""))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetUser_InvalidID(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{Logger: logger, Repo: &MockRepository{}}
+	rr := httptest.NewRecorder()
+
+	controller.GetUser(rr, newRequestWithID(t, "GET", "abc", ""))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUpdateUser_Success(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	var gotFields map[string]interface{}
+	mockRepo := &MockRepository{
+		UpdateUserDBFunc: func(ctx context.Context, userID string, fields map[string]interface{}) (*models.User, error) {
+			gotFields = fields
+			return &models.User{ID: userID, Name: "John Doe", Age: 45}, nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.UpdateUser(rr, newRequestWithID(t, "PATCH", testUserID, `{"age":45,"department_id":""}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 45, gotFields["age"])
+	assert.Nil(t, gotFields["department_id"])
+	assert.Contains(t, gotFields, "department_id")
+}
+
+func TestUpdateUser_EmptyBody(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{Logger: logger, Repo: &MockRepository{}}
+	rr := httptest.NewRecorder()
+
+	controller.UpdateUser(rr, newRequestWithID(t, "PATCH", testUserID, `{}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUpdateUser_Errors(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: ErrUserNotFound, want: http.StatusNotFound},
+		{err: ErrDuplicateEmail, want: http.StatusConflict},
+		{err: ErrInvalidDepartment, want: http.StatusBadRequest},
+		{err: errors.New("connection reset"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		mockRepo := &MockRepository{
+			UpdateUserDBFunc: func(ctx context.Context, userID string, fields map[string]interface{}) (*models.User, error) {
+				return nil, tt.err
+			},
+		}
+		controller := &Controller{Logger: logger, Repo: mockRepo}
+		rr := httptest.NewRecorder()
+
+		controller.UpdateUser(rr, newRequestWithID(t, "PATCH", testUserID, `{"email":"john@example.com"}`))
+
+		assert.Equal(t, tt.want, rr.Code, tt.err.Error())
+	}
+}
+
+func TestDeleteUser_Success(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		DeleteUserDBFunc: func(ctx context.Context, userID string) error {
+			return nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.DeleteUser(rr, newRequestWithID(t, "DELETE", testUserID, ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDeleteUser_NotFound(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		DeleteUserDBFunc: func(ctx context.Context, userID string) error {
+			return ErrUserNotFound
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+	rr := httptest.NewRecorder()
+
+	controller.DeleteUser(rr, newRequestWithID(t, "DELETE", testUserID, ""))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetLeaderboard_Success(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		GetUsersByIDsDBFunc: func(ctx context.Context, userIDs []string) ([]*models.User, error) {
+			return []*models.User{{ID: "1", Name: "Alice"}, {ID: "2", Name: "Bob"}, {ID: "3", Name: "Carol"}}, nil
+		},
+	}
+
+	_, conn, _ := mockgrpc.SetupGRPCServer(t)
+	defer conn.Close()
+
+	controller := &Controller{
+		Logger: logger,
+		Repo:   mockRepo,
+		PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+			GetFunc: func() (*grpc.ClientConn, error) { return conn, nil },
+			PutFunc: func(conn *grpc.ClientConn) {},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/users/leaderboard?limit=3", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	controller.GetLeaderboard(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Data models.ResponseLeaderboard `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, 3, body.Data.Limit)
+	assert.Len(t, body.Data.List, 3)
+	assert.Equal(t, "Carol", body.Data.List[2].Name)
+	assert.Equal(t, 2, body.Data.List[2].Rank)
+}
+
+func TestGetLeaderboard_InvalidQuery(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{Logger: logger, Repo: &MockRepository{}}
+
+	for _, query := range []string{"limit=0", "limit=101", "limit=abc", "department_id=hr"} {
+		req, err := http.NewRequest("GET", "/users/leaderboard?"+query, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		controller.GetLeaderboard(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestGetLeaderboard_PoolExhausted(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{
+		Logger: logger,
+		Repo:   &MockRepository{},
+		PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+			GetContextFunc: func(ctx context.Context) (*grpc.ClientConn, error) {
+				return nil, fmt.Errorf("%w: %w", container.ErrPoolExhausted, context.DeadlineExceeded)
+			},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/users/leaderboard", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	controller.GetLeaderboard(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestGetAllUsers_DegradedPoints(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	controller := &Controller{
+		Logger: logger,
+		Repo:   statisticsRepo(),
+		PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+			GetFunc: func() (*grpc.ClientConn, error) { return nil, errors.New("connection refused") },
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/users", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	controller.GetAllUsers(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Data struct {
+			Degraded []string                 `json:"Degraded"`
+			List     []map[string]interface{} `json:"List"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, []string{"points"}, body.Data.Degraded)
+	assert.Len(t, body.Data.List, 1)
+	point, ok := body.Data.List[0]["point"]
+	assert.True(t, ok)
+	assert.Nil(t, point)
+}
+
+func TestGetAllUsers_SalaryRedaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions auth.Permissions
+		wantSalary  bool
+	}{
+		{name: "With salary permission", permissions: auth.Permissions{auth.PermUsersRead: {}, auth.PermUsersReadSalary: {}}, wantSalary: true},
+		{name: "Without salary permission", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantSalary: false},
+		{name: "Without permissions", permissions: nil, wantSalary: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			repo := statisticsRepo()
+			repo.GetAllUserDBFunc = func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+				return []*models.User{{ID: "1", Name: "John", Salary: 50000}}, "1", nil
+			}
+			controller := &Controller{
+				Logger: logger,
+				Repo:   repo,
+				PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+					GetFunc: func() (*grpc.ClientConn, error) { return nil, errors.New("connection refused") },
+				},
+			}
+
+			req, err := http.NewRequest("GET", "/users", nil)
+			assert.NoError(t, err)
+			if tt.permissions != nil {
+				req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, tt.permissions))
+			}
+			rr := httptest.NewRecorder()
+
+			controller.GetAllUsers(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var body struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			for _, key := range []string{"HighSalary", "LowSalary", "AvgSalary", "MedianSalary", "P90Salary", "StdDevSalary"} {
+				_, ok := body.Data[key]
+				assert.Equal(t, tt.wantSalary, ok, key)
+			}
+			assert.Equal(t, "30.50", body.Data["AvgAge"])
+			assert.Equal(t, "30.00", body.Data["MedianAge"])
+			list, ok := body.Data["List"].([]interface{})
+			assert.True(t, ok)
+			assert.Len(t, list, 1)
+			_, ok = list[0].(map[string]interface{})["salary"]
+			assert.Equal(t, tt.wantSalary, ok)
+			assert.Equal(t, "John", list[0].(map[string]interface{})["name"])
+		})
+	}
+}
+
+func TestGetUser_SalaryRedaction(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &MockRepository{
+		GetUserDBFunc: func(ctx context.Context, userID string) (*models.User, error) {
+			return &models.User{ID: userID, Name: "John Doe", Salary: 50000}, nil
+		},
+	}
+	controller := &Controller{Logger: logger, Repo: mockRepo}
+
+	for _, permissions := range []auth.Permissions{{auth.PermUsersRead: {}}, {auth.AllPermissions: {}}} {
+		req := newRequestWithID(t, "GET", testUserID, "")
+		req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, permissions))
+		rr := httptest.NewRecorder()
+
+		controller.GetUser(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		_, ok := body.Data["salary"]
+		assert.Equal(t, permissions.Has(auth.PermUsersReadSalary), ok)
+		assert.Equal(t, "John Doe", body.Data["name"])
+	}
+}
+
+func TestGetAllUsers_SortBySalary(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions auth.Permissions
+		wantStatus  int
+		wantOrderBy string
+	}{
+		{name: "With salary permission", permissions: auth.Permissions{auth.PermUsersReadSalary: {}}, wantStatus: http.StatusOK, wantOrderBy: "COALESCE(age, 0) ASC, COALESCE(salary, 0) DESC, id ASC"},
+		{name: "Without salary permission", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			var gotOrderBy string
+			repo := statisticsRepo()
+			repo.GetAllUserDBFunc = func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+				gotOrderBy = orderBy
+				return []*models.User{}, "0", nil
+			}
+			controller := &Controller{
+				Logger: logger,
+				Repo:   repo,
+				PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+					GetFunc: func() (*grpc.ClientConn, error) { return nil, errors.New("connection refused") },
+				},
+			}
+
+			req, err := http.NewRequest("GET", "/users?orderby=age,-salary", nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, tt.permissions))
+			rr := httptest.NewRecorder()
+
+			controller.GetAllUsers(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantOrderBy, gotOrderBy)
+		})
+	}
+}
+
+func TestGetAllUsers_FilterBySalary(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions auth.Permissions
+		wantStatus  int
+		wantWhere   string
+	}{
+		{name: "With salary permission", permissions: auth.Permissions{auth.PermUsersReadSalary: {}}, wantStatus: http.StatusOK, wantWhere: "age >= ? AND salary < ?"},
+		{name: "Without salary permission", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			var gotWhere string
+			repo := statisticsRepo()
+			repo.GetAllUserDBFunc = func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+				gotWhere, _ = filter.Where()
+				return []*models.User{}, "0", nil
+			}
+			controller := &Controller{
+				Logger: logger,
+				Repo:   repo,
+				PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+					GetFunc: func() (*grpc.ClientConn, error) { return nil, errors.New("connection refused") },
+				},
+			}
+
+			req, err := http.NewRequest("GET", "/users?filter=age+gte+30+and+salary+lt+100000", nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, tt.permissions))
+			rr := httptest.NewRecorder()
+
+			controller.GetAllUsers(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantWhere, gotWhere)
+		})
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		permissions auth.Permissions
+		wantStatus  int
+		wantSalary  bool
+	}{
+		{name: "With salary permission", query: "?q=jo", permissions: auth.Permissions{auth.PermUsersReadSalary: {}}, wantStatus: http.StatusOK, wantSalary: true},
+		{name: "Without salary permission", query: "?q=jo", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantStatus: http.StatusOK},
+		{name: "Salary ordering needs permission", query: "?q=jo&orderby=salary", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantStatus: http.StatusBadRequest},
+		{name: "Missing term", query: "", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			controller := &Controller{
+				Logger: logger,
+				Repo: &MockRepository{
+					SearchUserDBFunc: func(ctx context.Context, term string, limit, offset int, orderBy string) ([]*models.User, string, error) {
+						assert.Equal(t, "jo", term)
+						assert.Equal(t, 20, limit)
+						return []*models.User{{ID: "1", Name: "John", Salary: 50000}}, "1", nil
+					},
+				},
+			}
+
+			req, err := http.NewRequest("GET", "/users/search"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, tt.permissions))
+			rr := httptest.NewRecorder()
+
+			controller.SearchUsers(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data struct {
+					Count string                   `json:"count"`
+					List  []map[string]interface{} `json:"list"`
+				} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "1", body.Data.Count)
+			assert.Len(t, body.Data.List, 1)
+			_, ok := body.Data.List[0]["salary"]
+			assert.Equal(t, tt.wantSalary, ok)
+		})
+	}
+}
+
+func TestGetAllUsers_StatsScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantScope  string
+		wantWhere  string
+	}{
+		{name: "Global by default", query: "?filter=age+gte+30", wantStatus: http.StatusOK, wantScope: StatsScopeGlobal},
+		{name: "Filtered", query: "?filter=age+gte+30&stats_scope=filtered", wantStatus: http.StatusOK, wantScope: StatsScopeFiltered, wantWhere: "age >= ?"},
+		{name: "Filtered without a filter", query: "?stats_scope=filtered", wantStatus: http.StatusOK, wantScope: StatsScopeFiltered},
+		{name: "Invalid", query: "?stats_scope=page", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotWhere string
+			repo := statisticsRepo()
+			repo.GetUserAggregatesDBFunc = func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+				gotWhere, _ = filter.Where()
+				return &models.UserAggregates{}, nil
+			}
+			controller := &Controller{
+				Logger: zap.NewNop(),
+				Repo:   repo,
+				PointServiceConnectionPool: &mockgrpc.MockConnectionPool{
+					GetFunc: func() (*grpc.ClientConn, error) { return nil, errors.New("connection refused") },
+				},
+			}
+
+			req, err := http.NewRequest("GET", "/users"+tt.query, nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			controller.GetAllUsers(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantWhere, gotWhere)
+			if tt.wantStatus == http.StatusOK {
+				var body struct {
+					Data map[string]interface{} `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantScope, body.Data["StatsScope"])
+			}
+		})
+	}
+}
+
+func TestGetUserStats(t *testing.T) {
+	financeID := "5f0c8c8e-2a4b-4d3e-9f5a-0b1c2d3e4f5a"
+	tests := []struct {
+		name            string
+		query           string
+		permissions     auth.Permissions
+		wantStatus      int
+		wantDepartments int
+		wantSalary      bool
+	}{
+		{name: "All users", query: "", permissions: auth.Permissions{auth.PermUsersReadSalary: {}}, wantStatus: http.StatusOK, wantSalary: true},
+		{name: "By department", query: "?group_by=department&filter=age+gte+30", permissions: auth.Permissions{auth.PermUsersReadSalary: {}}, wantStatus: http.StatusOK, wantDepartments: 2, wantSalary: true},
+		{name: "Without salary permission", query: "?group_by=department", permissions: auth.Permissions{auth.PermUsersRead: {}}, wantStatus: http.StatusOK, wantDepartments: 2},
+		{name: "Invalid group", query: "?group_by=age", wantStatus: http.StatusBadRequest},
+		{name: "Invalid filter", query: "?filter=bonus+gt+1", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregates := models.UserAggregates{
+				UserCount: 2,
+				Age:       models.FieldStatistics{Count: 2, Min: 30, Max: 40, Avg: 35, Median: 35, P90: 39},
+				Salary:    models.FieldStatistics{Count: 2, Min: 60000, Max: 80000, Avg: 70000, Median: 70000, P90: 78000},
+			}
+			controller := &Controller{
+				Logger: zap.NewNop(),
+				Repo: &MockRepository{
+					GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+						return &aggregates, nil
+					},
+					GetDepartmentAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) ([]*models.DepartmentUserAggregates, error) {
+						return []*models.DepartmentUserAggregates{
+							{DepartmentID: &financeID, UserAggregates: aggregates},
+							{UserAggregates: models.UserAggregates{UserCount: 1}},
+						}, nil
+					},
+				},
+			}
+
+			req, err := http.NewRequest("GET", "/users/stats"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), router.PermissionsKey, tt.permissions))
+			rr := httptest.NewRecorder()
+
+			controller.GetUserStats(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data models.ResponseUserStats `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, int64(2), body.Data.UserCount)
+			assert.Equal(t, 35.0, body.Data.Age.Median)
+			assert.Equal(t, tt.wantSalary, body.Data.Salary != nil)
+			assert.Len(t, body.Data.Departments, tt.wantDepartments)
+			for _, department := range body.Data.Departments {
+				assert.Equal(t, tt.wantSalary, department.Salary != nil)
+			}
+			if tt.wantDepartments > 0 {
+				assert.Equal(t, financeID, *body.Data.Departments[0].DepartmentID)
+				assert.Nil(t, body.Data.Departments[1].DepartmentID)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/syedomair/backend-microservices/models"
+)
+
+// newUserFromInput validates a create request and builds the user to insert.
+func newUserFromInput(input models.UserInput) (*models.User, error) {
+	if input.Name == nil || strings.TrimSpace(*input.Name) == "" {
+		return nil, errors.New("'name' is required")
+	}
+	if input.Email == nil || *input.Email == "" {
+		return nil, errors.New("'email' is required")
+	}
+	if err := validateUserInput(input); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:         strings.TrimSpace(*input.Name),
+		Email:        strings.ToLower(*input.Email),
+		DepartmentID: normalizeDepartmentID(input.DepartmentID),
+	}
+	if input.Age != nil {
+		user.Age = *input.Age
+	}
+	if input.Salary != nil {
+		user.Salary = *input.Salary
+	}
+	return user, nil
+}
+
+// updateFieldsFromInput validates a patch request and returns the columns to update.
+func updateFieldsFromInput(input models.UserInput) (map[string]interface{}, error) {
+	if input.Name != nil && strings.TrimSpace(*input.Name) == "" {
+		return nil, errors.New("'name' must not be empty")
+	}
+	if err := validateUserInput(input); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	if input.Name != nil {
+		fields["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.Email != nil {
+		fields["email"] = strings.ToLower(*input.Email)
+	}
+	if input.DepartmentID != nil {
+		fields["department_id"] = normalizeDepartmentID(input.DepartmentID)
+	}
+	if input.Age != nil {
+		fields["age"] = *input.Age
+	}
+	if input.Salary != nil {
+		fields["salary"] = *input.Salary
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("request body must set at least one field")
+	}
+	return fields, nil
+}
+
+func validateUserInput(input models.UserInput) error {
+	if input.Email != nil {
+		address, err := mail.ParseAddress(*input.Email)
+		if err != nil || address.Address != *input.Email {
+			return errors.New("'email' must be a valid email address")
+		}
+	}
+	if input.DepartmentID != nil && *input.DepartmentID != "" {
+		if _, err := uuid.Parse(*input.DepartmentID); err != nil {
+			return errors.New("'department_id' must be a UUID")
+		}
+	}
+	if input.Age != nil && *input.Age < 0 {
+		return errors.New("'age' must not be negative")
+	}
+	if input.Salary != nil && *input.Salary < 0 {
+		return errors.New("'salary' must not be negative")
+	}
+	return nil
+}
+
+// normalizeDepartmentID maps an empty department_id to NULL.
+func normalizeDepartmentID(departmentID *string) *string {
+	if departmentID == nil || *departmentID == "" {
+		return nil
+	}
+	return departmentID
+}
+
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// validateLeaderboardQuery reads the optional 'limit' and 'department_id' query parameters.
+func validateLeaderboardQuery(r *http.Request) (int, string, error) {
+	limit := defaultLeaderboardLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLeaderboardLimit {
+			return 0, "", errors.New("invalid 'limit' value in query string. Must be a number between 1 and 100. ")
+		}
+	}
+
+	departmentID := r.URL.Query().Get("department_id")
+	if departmentID != "" {
+		if _, err := uuid.Parse(departmentID); err != nil {
+			return 0, "", errors.New("invalid 'department_id' value in query string. Must be a UUID. ")
+		}
+	}
+	return limit, departmentID, nil
+}
+
+const (
+	// StatsScopeGlobal computes the statistics of a user list over all users.
+	StatsScopeGlobal = "global"
+	// StatsScopeFiltered computes them over the users matching the list filter.
+	StatsScopeFiltered = "filtered"
+
+	// GroupByDepartment adds the statistics of each department to the user statistics.
+	GroupByDepartment = "department"
+)
+
+// validateStatsScope reads the optional 'stats_scope' query parameter, StatsScopeGlobal by default.
+func validateStatsScope(r *http.Request) (string, error) {
+	switch scope := r.URL.Query().Get("stats_scope"); scope {
+	case "", StatsScopeGlobal:
+		return StatsScopeGlobal, nil
+	case StatsScopeFiltered:
+		return scope, nil
+	}
+	return "", errors.New("invalid 'stats_scope' value in query string. Must be 'filtered' or 'global'. ")
+}
+
+// validateGroupBy reads the optional 'group_by' query parameter of the statistics endpoint.
+func validateGroupBy(r *http.Request) (string, error) {
+	switch groupBy := r.URL.Query().Get("group_by"); groupBy {
+	case "", GroupByDepartment:
+		return groupBy, nil
+	}
+	return "", errors.New("invalid 'group_by' value in query string. Must be 'department'. ")
+}
@@ -1,44 +1,56 @@
 package user
 
 import (
+	"context"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 )
 
 // MockRepository is a manual mock implementation of the Repository interface.
 type MockRepository struct {
-	GetAllUserDBFunc      func(limit, offset int, orderBy, sort string) ([]*models.User, string, error)
-	GetUserHighAgeFunc    func() (int, error)
-	GetUserLowAgeFunc     func() (int, error)
-	GetUserAvgAgeFunc     func() (float64, error)
-	GetUserLowSalaryFunc  func() (float64, error)
-	GetUserHighSalaryFunc func() (float64, error)
-	GetUserAvgSalaryFunc  func() (float64, error)
+	GetAllUserDBFunc              func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error)
+	SearchUserDBFunc              func(ctx context.Context, term string, limit, offset int, orderBy string) ([]*models.User, string, error)
+	GetUserAggregatesDBFunc       func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error)
+	GetDepartmentAggregatesDBFunc func(ctx context.Context, filter *request.Filter) ([]*models.DepartmentUserAggregates, error)
+	CreateUserDBFunc              func(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserDBFunc                 func(ctx context.Context, userID string) (*models.User, error)
+	UpdateUserDBFunc              func(ctx context.Context, userID string, fields map[string]interface{}) (*models.User, error)
+	DeleteUserDBFunc              func(ctx context.Context, userID string) error
+	GetUsersByIDsDBFunc           func(ctx context.Context, userIDs []string) ([]*models.User, error)
 }
 
-func (m *MockRepository) GetAllUserDB(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
-	return m.GetAllUserDBFunc(limit, offset, orderBy, sort)
+func (m *MockRepository) GetAllUserDB(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+	return m.GetAllUserDBFunc(ctx, limit, offset, orderBy, keyset, filter)
 }
 
-func (m *MockRepository) GetUserHighAge() (int, error) {
-	return m.GetUserHighAgeFunc()
+func (m *MockRepository) SearchUserDB(ctx context.Context, term string, limit, offset int, orderBy string) ([]*models.User, string, error) {
+	return m.SearchUserDBFunc(ctx, term, limit, offset, orderBy)
 }
 
-func (m *MockRepository) GetUserLowAge() (int, error) {
-	return m.GetUserLowAgeFunc()
+func (m *MockRepository) GetUserAggregatesDB(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+	return m.GetUserAggregatesDBFunc(ctx, filter)
 }
 
-func (m *MockRepository) GetUserAvgAge() (float64, error) {
-	return m.GetUserAvgAgeFunc()
+func (m *MockRepository) GetDepartmentAggregatesDB(ctx context.Context, filter *request.Filter) ([]*models.DepartmentUserAggregates, error) {
+	return m.GetDepartmentAggregatesDBFunc(ctx, filter)
 }
 
-func (m *MockRepository) GetUserLowSalary() (float64, error) {
-	return m.GetUserLowSalaryFunc()
+func (m *MockRepository) CreateUserDB(ctx context.Context, user *models.User) (*models.User, error) {
+	return m.CreateUserDBFunc(ctx, user)
 }
 
-func (m *MockRepository) GetUserHighSalary() (float64, error) {
-	return m.GetUserHighSalaryFunc()
+func (m *MockRepository) GetUserDB(ctx context.Context, userID string) (*models.User, error) {
+	return m.GetUserDBFunc(ctx, userID)
 }
 
-func (m *MockRepository) GetUserAvgSalary() (float64, error) {
-	return m.GetUserAvgSalaryFunc()
+func (m *MockRepository) UpdateUserDB(ctx context.Context, userID string, fields map[string]interface{}) (*models.User, error) {
+	return m.UpdateUserDBFunc(ctx, userID, fields)
+}
+
+func (m *MockRepository) DeleteUserDB(ctx context.Context, userID string) error {
+	return m.DeleteUserDBFunc(ctx, userID)
+}
+
+func (m *MockRepository) GetUsersByIDsDB(ctx context.Context, userIDs []string) ([]*models.User, error) {
+	return m.GetUsersByIDsDBFunc(ctx, userIDs)
 }
@@ -1,16 +1,31 @@
 package user
 
 import (
+	"context"
+	"errors"
+
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 )
 
+var (
+	// ErrUserNotFound is returned when no user matches the given ID.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrDuplicateEmail is returned when the email is already taken by another user.
+	ErrDuplicateEmail = errors.New("a user with this email already exists")
+	// ErrInvalidDepartment is returned when department_id does not reference an existing department.
+	ErrInvalidDepartment = errors.New("department does not exist")
+)
+
 // Repository interface
 type Repository interface {
-	GetAllUserDB(limit int, offset int, orderby string, sort string) ([]*models.User, string, error)
-	GetUserHighAge() (int, error)
-	GetUserLowAge() (int, error)
-	GetUserAvgAge() (float64, error)
-	GetUserLowSalary() (float64, error)
-	GetUserHighSalary() (float64, error)
-	GetUserAvgSalary() (float64, error)
+	GetAllUserDB(ctx context.Context, limit int, offset int, orderby string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error)
+	SearchUserDB(ctx context.Context, term string, limit int, offset int, orderby string) ([]*models.User, string, error)
+	GetUserAggregatesDB(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error)
+	GetDepartmentAggregatesDB(ctx context.Context, filter *request.Filter) ([]*models.DepartmentUserAggregates, error)
+	CreateUserDB(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserDB(ctx context.Context, userID string) (*models.User, error)
+	UpdateUserDB(ctx context.Context, userID string, fields map[string]interface{}) (*models.User, error)
+	DeleteUserDB(ctx context.Context, userID string) error
+	GetUsersByIDsDB(ctx context.Context, userIDs []string) ([]*models.User, error)
 }
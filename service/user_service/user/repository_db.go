@@ -1,9 +1,17 @@
 package user
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/syedomair/backend-microservices/lib/logging"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/lib/search"
 	"github.com/syedomair/backend-microservices/models"
 
 	"go.uber.org/zap"
@@ -11,134 +19,371 @@ import (
 )
 
 type dbRepo struct {
-	client *gorm.DB
-	logger *zap.Logger
+	client   *gorm.DB
+	logger   *zap.Logger
+	searcher *search.Searcher
 }
 
 // NewDBRepository Public.
 func NewDBRepository(c *gorm.DB, logger *zap.Logger) Repository {
-	return &dbRepo{client: c, logger: logger}
+	return &dbRepo{client: c, logger: logger, searcher: search.New(c)}
 }
 
 // GetAllUserDB Public
-func (p *dbRepo) GetAllUserDB(limit int, offset int, orderby string, sort string) ([]*models.User, string, error) {
+// The page starts after keyset when it is set, and at offset otherwise. count is the total matching filter.
+func (p *dbRepo) GetAllUserDB(ctx context.Context, limit int, offset int, orderby string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 	methodName := "GetAllUserDB"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
 	users := []*models.User{}
 	count := int64(0)
-	if err := p.client.Table("public.user").
+	if err := p.filtered(ctx, filter).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+
+	query := p.filtered(ctx, filter).
 		Select("*").
 		Limit(limit).
+		Order(orderby)
+	if keyset != nil {
+		query = query.Where(keyset.Where, keyset.Args...)
+	} else {
+		query = query.Offset(offset)
+	}
+	if err := query.Scan(&users).Error; err != nil {
+		return nil, "", err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return users, strconv.Itoa(int(count)), nil
+}
+
+// filtered returns a query on the user table, bound to ctx, restricted to filter, which may be nil.
+func (p *dbRepo) filtered(ctx context.Context, filter *request.Filter) *gorm.DB {
+	query := p.client.WithContext(ctx).Table("user")
+	if where, args := filter.Where(); where != "" {
+		query = query.Where(where, args...)
+	}
+	return query
+}
+
+// SearchUserDB Public
+// Users match term on name or email and come most relevant first, then in orderby.
+func (p *dbRepo) SearchUserDB(ctx context.Context, term string, limit int, offset int, orderby string) ([]*models.User, string, error) {
+	methodName := "SearchUserDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	query := p.searcher.Query(ctx, term, "name", "email")
+	users := []*models.User{}
+	count := int64(0)
+	if err := p.client.WithContext(ctx).Table("user").Where(query.Where, query.Args...).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+
+	if err := p.client.WithContext(ctx).Table("user").
+		Select("*, "+query.Rank+" AS search_rank", query.RankArgs...).
+		Where(query.Where, query.Args...).
+		Order("search_rank DESC, " + orderby).
+		Limit(limit).
 		Offset(offset).
-		Order(orderby).
-		Scan(&users).Count(&count).Error; err != nil {
+		Scan(&users).Error; err != nil {
 		return nil, "", err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
 	return users, strconv.Itoa(int(count)), nil
 }
 
-// GetUserHighAge Public
-func (p *dbRepo) GetUserHighAge() (int, error) {
-	methodName := "GetUserHighAge"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+// GetUserAggregatesDB Public
+// Every statistic comes from a single statement over the users matching filter, which may be nil.
+// Users without an age or salary are left out of that column's statistics.
+func (p *dbRepo) GetUserAggregatesDB(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+	methodName := "GetUserAggregatesDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	row := aggregateRow{}
+	if err := p.aggregates(ctx, filter, "").Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return row.userAggregates(), nil
+}
+
+// GetDepartmentAggregatesDB Public
+// Like GetUserAggregatesDB, per department, in one statement. Users without a department form a
+// group with a nil DepartmentID.
+func (p *dbRepo) GetDepartmentAggregatesDB(ctx context.Context, filter *request.Filter) ([]*models.DepartmentUserAggregates, error) {
+	methodName := "GetDepartmentAggregatesDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	var highAge int
-	if err := p.client.Table("public.user").
-		Select("MAX(age)").
-		Scan(&highAge).Error; err != nil {
-		return 0, err
+	rows := []aggregateRow{}
+	if err := p.aggregates(ctx, filter, "department_id").
+		Group("department_id").
+		Order("department_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	departments := make([]*models.DepartmentUserAggregates, 0, len(rows))
+	for _, row := range rows {
+		departments = append(departments, &models.DepartmentUserAggregates{
+			DepartmentID:   row.DepartmentID,
+			UserAggregates: *row.userAggregates(),
+		})
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return highAge, nil
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return departments, nil
 }
 
-// GetUserLowAge Public
-func (p *dbRepo) GetUserLowAge() (int, error) {
-	methodName := "GetUserLowAge"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+// aggregates returns the aggregate statement over the users matching filter, computed separately
+// for each value of the group column when it is not empty. The caller groups the result by it.
+func (p *dbRepo) aggregates(ctx context.Context, filter *request.Filter, group string) *gorm.DB {
+	columns := rankedColumns("age", group) + ", " + rankedColumns("salary", group)
+	aggregates := "COUNT(*) AS user_count, " + aggregateColumns("age") + ", " + aggregateColumns("salary")
+	if group != "" {
+		columns = group + ", " + columns
+		aggregates = group + ", " + aggregates
+	}
+	return p.client.WithContext(ctx).
+		Table("(?) AS ranked", p.filtered(ctx, filter).Select(columns)).
+		Select(aggregates)
+}
+
+// CreateUserDB Public
+func (p *dbRepo) CreateUserDB(ctx context.Context, user *models.User) (*models.User, error) {
+	methodName := "CreateUserDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	var lowAge int
-	if err := p.client.Table("public.user").
-		Select("MIN(age)").
-		Scan(&lowAge).Error; err != nil {
-		return 0, err
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	if err := p.client.WithContext(ctx).Table("user").Create(user).Error; err != nil {
+		return nil, translateError(err)
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return lowAge, nil
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return user, nil
 }
 
-// GetUserAvgAge Public
-func (p *dbRepo) GetUserAvgAge() (float64, error) {
-	methodName := "GetUserAvgAge"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+// GetUserDB Public
+func (p *dbRepo) GetUserDB(ctx context.Context, userID string) (*models.User, error) {
+	methodName := "GetUserDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	var avgAge float64
-	if err := p.client.Table("public.user").
-		Select("AVG(age)").
-		Scan(&avgAge).Error; err != nil {
-		return 0, err
+	user, err := getUser(p.client.WithContext(ctx), userID)
+	if err != nil {
+		return nil, err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return avgAge, nil
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return user, nil
 }
 
-// GetUserLowSalary Public
-func (p *dbRepo) GetUserLowSalary() (float64, error) {
-	methodName := "GetUserLowSalary"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+// UpdateUserDB Public
+func (p *dbRepo) UpdateUserDB(ctx context.Context, userID string, fields map[string]interface{}) (*models.User, error) {
+	methodName := "UpdateUserDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	var lowSalary float64
-	if err := p.client.Table("public.user").
-		Select("MIN(salary)").
-		Scan(&lowSalary).Error; err != nil {
-		return 0, err
+	var user *models.User
+	err := p.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Check existence first: MySQL reports zero affected rows when the values are unchanged.
+		if _, err := getUser(tx, userID); err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			if err := tx.Table("user").
+				Where("id = ?", userID).
+				Updates(fields).Error; err != nil {
+				return translateError(err)
+			}
+		}
+		var err error
+		user, err = getUser(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return lowSalary, nil
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return user, nil
 }
 
-// GetUserHighSalary Public
-func (p *dbRepo) GetUserHighSalary() (float64, error) {
-	methodName := "GetUserHighSalary"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+// DeleteUserDB Public
+func (p *dbRepo) DeleteUserDB(ctx context.Context, userID string) error {
+	methodName := "DeleteUserDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	var highSalary float64
-	if err := p.client.Table("public.user").
-		Select("MAX(salary)").
-		Scan(&highSalary).Error; err != nil {
-		return 0, err
+	result := p.client.WithContext(ctx).Table("user").
+		Where("id = ?", userID).
+		Delete(&models.User{})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return highSalary, nil
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return nil
 }
 
-// GetUserAvgSalary Public
-func (p *dbRepo) GetUserAvgSalary() (float64, error) {
-	methodName := "GetUserAvgSalary"
-	p.logger.Debug("method start", zap.String("method_name", methodName))
+// GetUsersByIDsDB Public
+func (p *dbRepo) GetUsersByIDsDB(ctx context.Context, userIDs []string) ([]*models.User, error) {
+	methodName := "GetUsersByIDsDB"
+	logger := logging.FromContext(ctx, p.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	var avgSalary float64
-	if err := p.client.Table("public.user").
-		Select("AVG(salary)").
-		Scan(&avgSalary).Error; err != nil {
-		return 0, err
+	users := []*models.User{}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	if err := p.client.WithContext(ctx).Table("user").
+		Where("id in (?)", userIDs).
+		Find(&users).Error; err != nil {
+		return nil, err
 	}
 
-	p.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return avgSalary, nil
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return users, nil
+}
+
+// aggregateRow is a row of the aggregate statement.
+type aggregateRow struct {
+	DepartmentID *string
+	UserCount    int64
+	Age          fieldAggregate `gorm:"embedded;embeddedPrefix:age_"`
+	Salary       fieldAggregate `gorm:"embedded;embeddedPrefix:salary_"`
+}
+
+// fieldAggregate holds the aggregates of one column. The pointers are nil when the column has no
+// values.
+type fieldAggregate struct {
+	Count       int64    `gorm:"column:count"`
+	Min         *float64 `gorm:"column:min"`
+	Max         *float64 `gorm:"column:max"`
+	Avg         *float64 `gorm:"column:avg"`
+	SquaredDevs *float64 `gorm:"column:squared_devs"`
+	MedianLow   *float64 `gorm:"column:p50_low"`
+	MedianHigh  *float64 `gorm:"column:p50_high"`
+	P90Low      *float64 `gorm:"column:p90_low"`
+	P90High     *float64 `gorm:"column:p90_high"`
+}
+
+// rankedColumns selects column with its 1-based rank among the non-null values, their count and
+// their mean, for aggregateColumns. They are computed within each value of group when it is not
+// empty.
+func rankedColumns(column, group string) string {
+	partition, window := "", ""
+	if group != "" {
+		partition, window = group+", ", "PARTITION BY "+group
+	}
+	return fmt.Sprintf("%[1]s, "+
+		"ROW_NUMBER() OVER (PARTITION BY %[2]s%[1]s IS NULL ORDER BY %[1]s) AS %[1]s_rank, "+
+		"COUNT(%[1]s) OVER (%[3]s) AS %[1]s_total, "+
+		"AVG(%[1]s) OVER (%[3]s) AS %[1]s_mean", column, partition, window)
+}
+
+// aggregateColumns aggregates a column selected by rankedColumns into the fields of fieldAggregate.
+func aggregateColumns(column string) string {
+	return fmt.Sprintf("COUNT(%[1]s) AS %[1]s_count, "+
+		"MIN(%[1]s) AS %[1]s_min, "+
+		"MAX(%[1]s) AS %[1]s_max, "+
+		"AVG(%[1]s) AS %[1]s_avg, "+
+		"SUM((%[1]s - %[1]s_mean) * (%[1]s - %[1]s_mean)) AS %[1]s_squared_devs, ", column) +
+		percentileColumns(column, 50, "p50") + ", " +
+		percentileColumns(column, 90, "p90")
+}
+
+// percentileColumns selects the values on either side of the percent percentile of column. Its
+// 1-based position is percent*(total-1)/100 + 1; both sides are multiplied by 100 so the comparison
+// stays in exact integer arithmetic on every dialect.
+func percentileColumns(column string, percent int, name string) string {
+	position := fmt.Sprintf("%d * (%s_total - 1) + 100", percent, column)
+	return fmt.Sprintf("MAX(CASE WHEN 100 * %[1]s_rank <= %[2]s THEN %[1]s END) AS %[1]s_%[3]s_low, "+
+		"MIN(CASE WHEN 100 * %[1]s_rank >= %[2]s THEN %[1]s END) AS %[1]s_%[3]s_high", column, position, name)
+}
+
+func (r aggregateRow) userAggregates() *models.UserAggregates {
+	return &models.UserAggregates{
+		UserCount: r.UserCount,
+		Age:       r.Age.statistics(),
+		Salary:    r.Salary.statistics(),
+	}
+}
+
+// statistics interpolates the percentiles and derives the standard deviation.
+func (a fieldAggregate) statistics() models.FieldStatistics {
+	if a.Count == 0 {
+		return models.FieldStatistics{}
+	}
+	statistics := models.FieldStatistics{
+		Count:  a.Count,
+		Min:    value(a.Min),
+		Max:    value(a.Max),
+		Avg:    value(a.Avg),
+		Median: interpolate(a.MedianLow, a.MedianHigh, 50, a.Count),
+		P90:    interpolate(a.P90Low, a.P90High, 90, a.Count),
+	}
+	if a.Count > 1 {
+		statistics.StdDev = math.Sqrt(value(a.SquaredDevs) / float64(a.Count-1))
+	}
+	return statistics
+}
+
+// interpolate returns the percent percentile of count values from the values on either side of it,
+// like percentile_cont.
+func interpolate(low, high *float64, percent int, count int64) float64 {
+	fraction := float64(int64(percent)*(count-1)%100) / 100
+	return value(low) + (value(high)-value(low))*fraction
+}
+
+func value(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func getUser(db *gorm.DB, userID string) (*models.User, error) {
+	user := &models.User{}
+	if err := db.Table("user").
+		Where("id = ?", userID).
+		Take(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// translateError maps constraint violations reported by the database to repository errors.
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateEmail
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return ErrInvalidDepartment
+	}
+	return err
 }
@@ -1,23 +1,25 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 )
 
 func TestGetAllUserDB_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return []*models.User{{ID: "1", Name: "John Doe", Age: 30, Salary: 50000.0}}, "1", nil
 		},
 	}
 
 	// Act
-	users, count, err := mockRepo.GetAllUserDB(10, 0, "name", "asc")
+	users, count, err := mockRepo.GetAllUserDB(context.Background(), 10, 0, "name", nil, nil)
 
 	// Assert
 	assert.NoError(t, err)
@@ -29,13 +31,13 @@ func TestGetAllUserDB_Success(t *testing.T) {
 func TestGetAllUserDB_Error(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return nil, "", errors.New("database error")
 		},
 	}
 
 	// Act
-	users, count, err := mockRepo.GetAllUserDB(10, 0, "name", "asc")
+	users, count, err := mockRepo.GetAllUserDB(context.Background(), 10, 0, "name", nil, nil)
 
 	// Assert
 	assert.Error(t, err)
@@ -43,194 +45,67 @@ func TestGetAllUserDB_Error(t *testing.T) {
 	assert.Equal(t, "", count)
 }
 
-func TestGetUserHighAge_Success(t *testing.T) {
+func TestGetUserAggregatesDB_Success(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetUserHighAgeFunc: func() (int, error) {
-			return 40, nil
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return &models.UserAggregates{UserCount: 2, Age: models.FieldStatistics{Count: 2, Min: 20, Max: 40, Median: 30}}, nil
 		},
 	}
 
 	// Act
-	highAge, err := mockRepo.GetUserHighAge()
+	aggregates, err := mockRepo.GetUserAggregatesDB(context.Background(), nil)
 
 	// Assert
 	assert.NoError(t, err)
-	assert.Equal(t, 40, highAge)
+	assert.Equal(t, 40.0, aggregates.Age.Max)
+	assert.Equal(t, 30.0, aggregates.Age.Median)
 }
 
-func TestGetUserHighAge_Error(t *testing.T) {
+func TestGetUserAggregatesDB_Error(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetUserHighAgeFunc: func() (int, error) {
-			return 0, errors.New("database error")
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return nil, errors.New("database error")
 		},
 	}
 
 	// Act
-	highAge, err := mockRepo.GetUserHighAge()
+	aggregates, err := mockRepo.GetUserAggregatesDB(context.Background(), nil)
 
 	// Assert
 	assert.Error(t, err)
-	assert.Equal(t, 0, highAge)
+	assert.Nil(t, aggregates)
 }
 
-func TestGetUserLowAge_Success(t *testing.T) {
+func TestGetUserDB_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetUserLowAgeFunc: func() (int, error) {
-			return 20, nil
+		GetUserDBFunc: func(ctx context.Context, userID string) (*models.User, error) {
+			return nil, ErrUserNotFound
 		},
 	}
 
 	// Act
-	lowAge, err := mockRepo.GetUserLowAge()
+	user, err := mockRepo.GetUserDB(context.Background(), "10")
 
 	// Assert
-	assert.NoError(t, err)
-	assert.Equal(t, 20, lowAge)
-}
-
-func TestGetUserLowAge_Error(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserLowAgeFunc: func() (int, error) {
-			return 0, errors.New("database error")
-		},
-	}
-
-	// Act
-	lowAge, err := mockRepo.GetUserLowAge()
-
-	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, 0, lowAge)
-}
-
-func TestGetUserAvgAge_Success(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 30.5, nil
-		},
-	}
-
-	// Act
-	avgAge, err := mockRepo.GetUserAvgAge()
-
-	// Assert
-	assert.NoError(t, err)
-	assert.Equal(t, 30.5, avgAge)
-}
-
-func TestGetUserAvgAge_Error(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 0, errors.New("database error")
-		},
-	}
-
-	// Act
-	avgAge, err := mockRepo.GetUserAvgAge()
-
-	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, 0.0, avgAge)
-}
-
-func TestGetUserLowSalary_Success(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 30000.0, nil
-		},
-	}
-
-	// Act
-	lowSalary, err := mockRepo.GetUserLowSalary()
-
-	// Assert
-	assert.NoError(t, err)
-	assert.Equal(t, 30000.0, lowSalary)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, user)
 }
 
-func TestGetUserLowSalary_Error(t *testing.T) {
+func TestCreateUserDB_DuplicateEmail(t *testing.T) {
 	// Arrange
 	mockRepo := &MockRepository{
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 0, errors.New("database error")
+		CreateUserDBFunc: func(ctx context.Context, user *models.User) (*models.User, error) {
+			return nil, ErrDuplicateEmail
 		},
 	}
 
 	// Act
-	lowSalary, err := mockRepo.GetUserLowSalary()
+	user, err := mockRepo.CreateUserDB(context.Background(), &models.User{Name: "John Doe", Email: "john@example.com"})
 
 	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, 0.0, lowSalary)
-}
-
-func TestGetUserHighSalary_Success(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 100000.0, nil
-		},
-	}
-
-	// Act
-	highSalary, err := mockRepo.GetUserHighSalary()
-
-	// Assert
-	assert.NoError(t, err)
-	assert.Equal(t, 100000.0, highSalary)
-}
-
-func TestGetUserHighSalary_Error(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 0, errors.New("database error")
-		},
-	}
-
-	// Act
-	highSalary, err := mockRepo.GetUserHighSalary()
-
-	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, 0.0, highSalary)
-}
-
-func TestGetUserAvgSalary_Success(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 65000.0, nil
-		},
-	}
-
-	// Act
-	avgSalary, err := mockRepo.GetUserAvgSalary()
-
-	// Assert
-	assert.NoError(t, err)
-	assert.Equal(t, 65000.0, avgSalary)
-}
-
-func TestGetUserAvgSalary_Error(t *testing.T) {
-	// Arrange
-	mockRepo := &MockRepository{
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 0, errors.New("database error")
-		},
-	}
-
-	// Act
-	avgSalary, err := mockRepo.GetUserAvgSalary()
-
-	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, 0.0, avgSalary)
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+	assert.Nil(t, user)
 }
@@ -0,0 +1,349 @@
+package user
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syedomair/backend-microservices/lib/auth"
+	"github.com/syedomair/backend-microservices/lib/request"
+	"github.com/syedomair/backend-microservices/lib/router"
+	"github.com/syedomair/backend-microservices/lib/testdb"
+	"github.com/syedomair/backend-microservices/models"
+	"go.uber.org/zap/zaptest"
+	"gorm.io/gorm"
+)
+
+func newSQLiteRepo(t *testing.T) (Repository, *gorm.DB) {
+	db := testdb.New(t)
+	return NewDBRepository(db, zaptest.NewLogger(t)), db
+}
+
+func departmentID(t *testing.T, db *gorm.DB, name string) string {
+	department := models.Department{}
+	require.NoError(t, db.Where("name = ?", name).Take(&department).Error)
+	return department.ID
+}
+
+func TestSQLite_GetAllUserDBAndStatistics(t *testing.T) {
+	repo, _ := newSQLiteRepo(t)
+
+	users, count, err := repo.GetAllUserDB(context.Background(), 5, 0, "name", nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, users, 5)
+	assert.Equal(t, "9", count)
+	assert.Equal(t, "Alice Johnson", users[0].Name)
+
+	users, _, err = repo.GetAllUserDB(context.Background(), 3, 0, "salary DESC, name ASC", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"George Costanza", "Ian Malcolm", "Diana Prince"}, []string{users[0].Name, users[1].Name, users[2].Name})
+
+	aggregates, err := repo.GetUserAggregatesDB(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, aggregates.Age.Max)
+	assert.Equal(t, 48000.0, aggregates.Salary.Min)
+}
+
+func TestSQLite_GetUserAggregatesDB(t *testing.T) {
+	repo, db := newSQLiteRepo(t)
+
+	aggregates, err := repo.GetUserAggregatesDB(context.Background(), nil)
+	require.NoError(t, err)
+	ages := []float64{22, 27, 28, 29, 30, 32, 35, 38, 40}
+	assert.Equal(t, int64(9), aggregates.UserCount)
+	assert.Equal(t, int64(9), aggregates.Age.Count)
+	assert.Equal(t, 22.0, aggregates.Age.Min)
+	assert.Equal(t, 40.0, aggregates.Age.Max)
+	assert.InDelta(t, 281.0/9, aggregates.Age.Avg, 1e-9)
+	assert.Equal(t, 30.0, aggregates.Age.Median)
+	assert.InDelta(t, 38.4, aggregates.Age.P90, 1e-9)
+	assert.InDelta(t, sampleStdDev(ages), aggregates.Age.StdDev, 1e-9)
+	assert.Equal(t, 70000.0, aggregates.Salary.Median)
+	assert.InDelta(t, 86000.0, aggregates.Salary.P90, 1e-9)
+
+	// Missing values are left out, and an even count interpolates the median.
+	require.NoError(t, db.Exec(`INSERT INTO "user" (id, name, email, age, salary) VALUES
+		('00000000-0000-4000-8000-000000000001', 'No Age', 'no.age@example.com', NULL, NULL),
+		('00000000-0000-4000-8000-000000000002', 'Oldest', 'oldest@example.com', 50, NULL)`).Error)
+	aggregates, err = repo.GetUserAggregatesDB(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), aggregates.UserCount)
+	assert.Equal(t, int64(10), aggregates.Age.Count)
+	assert.Equal(t, int64(9), aggregates.Salary.Count)
+	assert.Equal(t, 31.0, aggregates.Age.Median)
+	assert.InDelta(t, sampleStdDev(append(ages, 50)), aggregates.Age.StdDev, 1e-9)
+	assert.Equal(t, 70000.0, aggregates.Salary.Median)
+
+	require.NoError(t, db.Exec(`DELETE FROM "user"`).Error)
+	aggregates, err = repo.GetUserAggregatesDB(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &models.UserAggregates{}, aggregates)
+}
+
+func TestSQLite_GetUserAggregatesDBFilter(t *testing.T) {
+	repo, db := newSQLiteRepo(t)
+
+	req, err := http.NewRequest("GET", "/users?filter="+url.QueryEscape("department_id eq "+departmentID(t, db, "Human Resources")), nil)
+	require.NoError(t, err)
+	filter, err := request.ValidateFilter(req, request.FilterFields{"department_id": {Type: request.UUID, Operators: []string{request.Eq}}})
+	require.NoError(t, err)
+
+	aggregates, err := repo.GetUserAggregatesDB(context.Background(), filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), aggregates.UserCount)
+	assert.Equal(t, 28.0, aggregates.Age.Min)
+	assert.Equal(t, 30.0, aggregates.Age.Median)
+	assert.InDelta(t, 34.0, aggregates.Age.P90, 1e-9)
+	assert.InDelta(t, 185000.0/3, aggregates.Salary.Avg, 1e-9)
+}
+
+func TestSQLite_GetDepartmentAggregatesDB(t *testing.T) {
+	repo, db := newSQLiteRepo(t)
+	require.NoError(t, db.Exec(`INSERT INTO "user" (id, name, email, age, salary) VALUES
+		('00000000-0000-4000-8000-000000000001', 'No Department', 'no.department@example.com', 50, NULL)`).Error)
+
+	departments, err := repo.GetDepartmentAggregatesDB(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, departments, 4)
+
+	byID := map[string]*models.DepartmentUserAggregates{}
+	for _, department := range departments {
+		id := ""
+		if department.DepartmentID != nil {
+			id = *department.DepartmentID
+		}
+		byID[id] = department
+	}
+	tests := []struct {
+		department string
+		ages       []float64
+		salaries   []float64
+	}{
+		{department: "Human Resources", ages: []float64{28, 30, 35}, salaries: []float64{55000, 60000, 70000}},
+		{department: "Finance", ages: []float64{27, 29, 32}, salaries: []float64{52000, 75000, 80000}},
+		{department: "IT Support", ages: []float64{22, 38, 40}, salaries: []float64{48000, 85000, 90000}},
+	}
+	for _, tt := range tests {
+		department := byID[departmentID(t, db, tt.department)]
+		require.NotNil(t, department, tt.department)
+		assert.Equal(t, int64(3), department.UserCount, tt.department)
+		assert.Equal(t, tt.ages[1], department.Age.Median, tt.department)
+		assert.Equal(t, tt.ages[2], department.Age.Max, tt.department)
+		assert.InDelta(t, sampleStdDev(tt.ages), department.Age.StdDev, 1e-9, tt.department)
+		assert.Equal(t, tt.salaries[1], department.Salary.Median, tt.department)
+		assert.InDelta(t, tt.salaries[1]+(tt.salaries[2]-tt.salaries[1])*0.8, department.Salary.P90, 1e-9, tt.department)
+	}
+
+	// Users without a department are counted in a group of their own.
+	assert.Equal(t, &models.DepartmentUserAggregates{UserAggregates: models.UserAggregates{
+		UserCount: 1,
+		Age:       models.FieldStatistics{Count: 1, Min: 50, Max: 50, Avg: 50, Median: 50, P90: 50},
+	}}, byID[""])
+}
+
+func sampleStdDev(values []float64) float64 {
+	mean := 0.0
+	for _, v := range values {
+		mean += v / float64(len(values))
+	}
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(squares / float64(len(values)-1))
+}
+
+func TestSQLite_UserCRUD(t *testing.T) {
+	repo, db := newSQLiteRepo(t)
+	financeID := departmentID(t, db, "Finance")
+
+	created, err := repo.CreateUserDB(context.Background(), &models.User{Name: "Jane Doe", Email: "jane@example.com", DepartmentID: &financeID, Age: 31, Salary: 72000})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+
+	_, err = repo.CreateUserDB(context.Background(), &models.User{Name: "Jane Again", Email: "jane@example.com"})
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+
+	missing := "00000000-0000-4000-8000-000000000000"
+	_, err = repo.CreateUserDB(context.Background(), &models.User{Name: "Nobody", Email: "nobody@example.com", DepartmentID: &missing})
+	assert.ErrorIs(t, err, ErrInvalidDepartment)
+
+	updated, err := repo.UpdateUserDB(context.Background(), created.ID, map[string]interface{}{"age": 32, "department_id": nil})
+	require.NoError(t, err)
+	assert.Equal(t, 32, updated.Age)
+	assert.Nil(t, updated.DepartmentID)
+
+	found, err := repo.GetUsersByIDsDB(context.Background(), []string{created.ID, missing})
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	require.NoError(t, repo.DeleteUserDB(context.Background(), created.ID))
+	_, err = repo.GetUserDB(context.Background(), created.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.ErrorIs(t, repo.DeleteUserDB(context.Background(), created.ID), ErrUserNotFound)
+}
+
+func TestSQLite_GetAllUserDBCursorPagination(t *testing.T) {
+	repo, _ := newSQLiteRepo(t)
+	cursors := request.NewCursorCodec([]byte("secret"))
+
+	fetch := func(query url.Values) ([]string, request.Cursors) {
+		t.Helper()
+		req, err := http.NewRequest("GET", "/users?"+query.Encode(), nil)
+		require.NoError(t, err)
+		params, err := request.ValidateQueryString(req, "4", "0", "name", "asc", append(request.NotNull("id", "name"), request.SortColumn{Name: "age", NullAs: "0"}), cursors)
+		require.NoError(t, err)
+		users, count, err := repo.GetAllUserDB(context.Background(), params.FetchLimit(), params.Page, params.OrderBy, params.Keyset, nil)
+		require.NoError(t, err)
+		total, err := strconv.Atoi(count)
+		require.NoError(t, err)
+		users, page, err := request.Paginate(params, users, total)
+		require.NoError(t, err)
+		names := []string{}
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		return names, page
+	}
+
+	first, page := fetch(url.Values{"orderby": {"-age"}})
+	assert.Equal(t, []string{"George Costanza", "Ian Malcolm", "Charlie Brown", "Diana Prince"}, first)
+	assert.Empty(t, page.Prev)
+
+	second, page := fetch(url.Values{"cursor": {page.Next}})
+	assert.Equal(t, []string{"Alice Johnson", "Ethan Hunt", "Bob Smith", "Fiona Gallagher"}, second)
+
+	last, page := fetch(url.Values{"cursor": {page.Next}})
+	assert.Equal(t, []string{"Hannah Baker"}, last)
+	assert.Empty(t, page.Next)
+
+	back, _ := fetch(url.Values{"cursor": {page.Prev}})
+	assert.Equal(t, second, back)
+
+	offsetPage, _ := fetch(url.Values{"orderby": {"-age"}, "page": {"2"}})
+	assert.Equal(t, second, offsetPage)
+}
+
+func TestSQLite_GetAllUserDBCursorPaginationNulls(t *testing.T) {
+	repo, db := newSQLiteRepo(t)
+	require.NoError(t, db.Exec(`INSERT INTO "user" (name, email, age, salary) VALUES
+		('Nora Null', 'nora.null@example.com', NULL, NULL),
+		('Oscar Odd', 'oscar.odd@example.com', 31, 55000.1)`).Error)
+	cursors := request.NewCursorCodec([]byte("secret"))
+	ctx := context.WithValue(context.Background(), router.PermissionsKey, auth.Permissions{auth.PermUsersReadSalary: {}})
+
+	// pageAll follows the next cursors from the first page and returns every name seen, in order.
+	pageAll := func(orderby string) []string {
+		t.Helper()
+		names := []string{}
+		query := url.Values{"orderby": {orderby}}
+		for pages := 0; pages < 10; pages++ {
+			req, err := http.NewRequestWithContext(ctx, "GET", "/users?"+query.Encode(), nil)
+			require.NoError(t, err)
+			params, err := request.ValidateQueryString(req, "3", "0", "name", "asc", sortColumns(req), cursors)
+			require.NoError(t, err)
+			users, count, err := repo.GetAllUserDB(ctx, params.FetchLimit(), params.Page, params.OrderBy, params.Keyset, nil)
+			require.NoError(t, err)
+			total, err := strconv.Atoi(count)
+			require.NoError(t, err)
+			users, page, err := request.Paginate(params, users, total)
+			require.NoError(t, err)
+			for _, user := range users {
+				names = append(names, user.Name)
+			}
+			if page.Next == "" {
+				return names
+			}
+			query = url.Values{"cursor": {page.Next}}
+		}
+		t.Fatalf("paging by %s did not end", orderby)
+		return nil
+	}
+
+	for _, orderby := range []string{"age", "-age", "salary", "-salary"} {
+		t.Run(orderby, func(t *testing.T) {
+			names := pageAll(orderby)
+			assert.Len(t, names, 11)
+			assert.ElementsMatch(t, names, pageAll("name"), "every user is listed exactly once")
+		})
+	}
+	// A null age is listed as 0, and sorts as 0.
+	assert.Equal(t, "Nora Null", pageAll("age")[0])
+	assert.Equal(t, "Nora Null", pageAll("-age")[10])
+}
+
+func TestSQLite_GetAllUserDBFilter(t *testing.T) {
+	repo, db := newSQLiteRepo(t)
+	financeID := departmentID(t, db, "Finance")
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		wantNames []string
+	}{
+		{name: "Expression", query: url.Values{"filter": {"department_id eq " + financeID + " and age gte 29"}}, wantNames: []string{"Diana Prince", "Ethan Hunt"}},
+		{name: "Brackets", query: url.Values{"age[gt]": {"35"}, "salary[lt]": {"90000"}}, wantNames: []string{"Ian Malcolm"}},
+		{name: "In", query: url.Values{"filter": {"age in 22, 40"}}, wantNames: []string{"George Costanza", "Hannah Baker"}},
+		{name: "Contains ignores case and wildcards", query: url.Values{"filter": {"name contains 'BROWN'"}, "email[contains]": {"%"}}, wantNames: []string{}},
+		{name: "Contains", query: url.Values{"filter": {"name contains 'ROWN'"}}, wantNames: []string{"Charlie Brown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/users?"+tt.query.Encode(), nil)
+			require.NoError(t, err)
+			filter, err := request.ValidateFilter(req, request.FilterFields{
+				"department_id": {Type: request.UUID, Operators: []string{request.Eq}},
+				"name":          {Type: request.String, Operators: []string{request.Contains}},
+				"email":         {Type: request.String, Operators: []string{request.Contains}},
+				"age":           {Type: request.Int, Operators: request.Comparisons},
+				"salary":        {Type: request.Float, Operators: request.Comparisons},
+			})
+			require.NoError(t, err)
+
+			users, count, err := repo.GetAllUserDB(context.Background(), 10, 0, "name", nil, filter)
+			require.NoError(t, err)
+			names := []string{}
+			for _, user := range users {
+				names = append(names, user.Name)
+			}
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, strconv.Itoa(len(tt.wantNames)), count)
+		})
+	}
+}
+
+func TestSQLite_SearchUserDB(t *testing.T) {
+	repo, _ := newSQLiteRepo(t)
+
+	users, count, err := repo.SearchUserDB(context.Background(), "HA", 2, 0, "name ASC, id ASC")
+	require.NoError(t, err)
+	assert.Equal(t, "3", count)
+	assert.Equal(t, []string{"Hannah Baker", "Charlie Brown"}, []string{users[0].Name, users[1].Name}, "prefix matches rank first")
+
+	users, _, err = repo.SearchUserDB(context.Background(), "HA", 2, 2, "name ASC, id ASC")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ethan Hunt"}, []string{users[0].Name})
+
+	users, count, err = repo.SearchUserDB(context.Background(), "nobody", 10, 0, "name ASC, id ASC")
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Equal(t, "0", count)
+}
+
+func TestSQLite_CanceledContext(t *testing.T) {
+	repo, _ := newSQLiteRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := repo.GetAllUserDB(ctx, 5, 0, "name", nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetUserAggregatesDB(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.UpdateUserDB(ctx, "00000000-0000-4000-8000-000000000000", map[string]interface{}{"name": "Nobody"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -2,10 +2,14 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/syedomair/backend-microservices/lib/breaker"
 	"github.com/syedomair/backend-microservices/lib/container"
+	"github.com/syedomair/backend-microservices/lib/logging"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 	pb "github.com/syedomair/backend-microservices/proto/v1/point"
 	"go.uber.org/zap"
@@ -16,6 +20,7 @@ import (
 type PointServiceClientInterface interface {
 	GetUserPoints(ctx context.Context, in *pb.PointRequest, opts ...grpc.CallOption) (*pb.PointReply, error)
 	GetUserListPoints(ctx context.Context, in *pb.UserListRequest, opts ...grpc.CallOption) (*pb.UserListPointResponse, error)
+	GetLeaderboard(ctx context.Context, in *pb.LeaderboardRequest, opts ...grpc.CallOption) (*pb.LeaderboardReply, error)
 }
 
 type UserService struct {
@@ -23,62 +28,48 @@ type UserService struct {
 	logger                     *zap.Logger
 	pointServiceClient         PointServiceClientInterface
 	pointServiceConnectionPool container.ConnectionPoolInterface
+	pointServiceBreaker        *breaker.Breaker
 }
 
-func NewUserService(repo Repository, logger *zap.Logger, pointServiceClient PointServiceClientInterface, pool container.ConnectionPoolInterface) *UserService {
-	return &UserService{repo: repo, logger: logger, pointServiceClient: pointServiceClient, pointServiceConnectionPool: pool}
+func NewUserService(repo Repository, logger *zap.Logger, pointServiceClient PointServiceClientInterface, pool container.ConnectionPoolInterface, pointBreaker *breaker.Breaker) *UserService {
+	return &UserService{repo: repo, logger: logger, pointServiceClient: pointServiceClient, pointServiceConnectionPool: pool, pointServiceBreaker: pointBreaker}
 }
 
 // GetAllUserStatistics
-func (u *UserService) GetAllUserStatistics(limit, offset int, orderBy, sort string) (*models.UserStatistics, error) {
+// The statistics cover the users matching filter when statsScope is StatsScopeFiltered, and all users otherwise.
+func (u *UserService) GetAllUserStatistics(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter, statsScope string) (*models.UserStatistics, error) {
 	methodName := "GetAllUserStatistics"
-	u.logger.Debug("method start", zap.String("method_name", methodName))
+	logger := logging.FromContext(ctx, u.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
 	start := time.Now()
 
-	g, _ := errgroup.WithContext(context.Background())
+	g, ctx := errgroup.WithContext(ctx)
 
 	var (
-		userList          []*models.User
-		count             string
-		intUserHighAge    int
-		intUserLowAge     int
-		fltUserAvgAge     float64
-		fltUserAvgSalary  float64
-		fltUserLowSalary  float64
-		fltUserHighSalary float64
+		userList   []*models.User
+		count      string
+		aggregates *models.UserAggregates
+		degraded   []string
 	)
 
 	g.Go(func() error {
 		var err error
-		userList, count, err = u.repo.GetAllUserDB(limit, offset, orderBy, sort)
+		userList, count, err = u.repo.GetAllUserDB(ctx, limit, offset, orderBy, keyset, filter)
 		if err != nil {
 			return err
 		}
 
-		conn, err := u.pointServiceConnectionPool.Get()
-		if err != nil {
-			return fmt.Errorf("failed to get connection from pool: %v", err)
-		}
-		defer u.pointServiceConnectionPool.Put(conn)
-
-		client := pb.NewPointServerClient(conn)
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
 		userIDs := []string{}
 		for _, user := range userList {
 			userIDs = append(userIDs, user.ID)
 		}
 
-		r, err := client.GetUserListPoints(ctx, &pb.UserListRequest{UserIds: userIDs})
+		// Points are optional: the list is still returned, with null points, when point_service is unavailable.
+		userPoints, err := u.getUserListPoints(ctx, userIDs)
 		if err != nil {
-			u.logger.Error("failed to get points for users", zap.Error(err), zap.Any("userIDs", userIDs))
-			return err
-		}
-		userPoints := r.GetUserPoints()
-		for k, v := range userPoints {
-			u.logger.Debug("user points", zap.String("user_id", k), zap.Any("points", v))
+			logger.Warn("points unavailable, returning degraded user list", zap.Error(err), zap.Any("userIDs", userIDs))
+			degraded = append(degraded, models.DegradedPoints)
+			return nil
 		}
 
 		userList = updateUserListWithPoints(userList, userPoints)
@@ -88,83 +79,165 @@ func (u *UserService) GetAllUserStatistics(limit, offset int, orderBy, sort stri
 	})
 
 	g.Go(func() error {
-		var err error
-		intUserHighAge, err = u.repo.GetUserHighAge()
-		if err != nil {
-			return err
+		var statsFilter *request.Filter
+		if statsScope == StatsScopeFiltered {
+			statsFilter = filter
 		}
-		return nil
+		var err error
+		aggregates, err = u.repo.GetUserAggregatesDB(ctx, statsFilter)
+		return err
 	})
 
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	userStatistics := &models.UserStatistics{
+		UserList:   userList,
+		Count:      count,
+		Aggregates: *aggregates,
+		Degraded:   degraded,
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return userStatistics, nil
+}
+
+// GetUserAggregates returns the statistics of the users matching filter and, when byDepartment is
+// set, of each department. Both queries run concurrently.
+func (u *UserService) GetUserAggregates(ctx context.Context, filter *request.Filter, byDepartment bool) (*models.UserAggregates, []*models.DepartmentUserAggregates, error) {
+	methodName := "GetUserAggregates"
+	logger := logging.FromContext(ctx, u.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	var (
+		aggregates  *models.UserAggregates
+		departments []*models.DepartmentUserAggregates
+	)
+
 	g.Go(func() error {
 		var err error
-		intUserLowAge, err = u.repo.GetUserLowAge()
-		if err != nil {
-			return err
-		}
-		return nil
+		aggregates, err = u.repo.GetUserAggregatesDB(ctx, filter)
+		return err
 	})
 
-	g.Go(func() error {
-		var err error
-		fltUserAvgAge, err = u.repo.GetUserAvgAge()
-		if err != nil {
+	if byDepartment {
+		g.Go(func() error {
+			var err error
+			departments, err = u.repo.GetDepartmentAggregatesDB(ctx, filter)
 			return err
-		}
-		return nil
-	})
+		})
+	}
 
-	g.Go(func() error {
-		var err error
-		fltUserLowSalary, err = u.repo.GetUserLowSalary()
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return aggregates, departments, nil
+}
+
+// GetLeaderboard ranks users by points and attaches their names.
+func (u *UserService) GetLeaderboard(ctx context.Context, limit int, departmentID string) ([]*models.LeaderboardEntry, error) {
+	methodName := "GetLeaderboard"
+	logger := logging.FromContext(ctx, u.logger)
+	logger.Debug("method start", zap.String("method_name", methodName))
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var r *pb.LeaderboardReply
+	err := u.pointServiceBreaker.Execute(func() error {
+		conn, err := u.pointServiceConnectionPool.GetContext(ctx)
 		if err != nil {
-			return err
+			return callError(ctx, fmt.Errorf("failed to get connection from pool: %w", err))
 		}
-		return nil
+		defer u.pointServiceConnectionPool.Put(conn)
+
+		r, err = pb.NewPointServerClient(conn).GetLeaderboard(ctx, &pb.LeaderboardRequest{Limit: int32(limit), DepartmentId: departmentID})
+		return callError(ctx, err)
 	})
+	if err != nil {
+		logger.Error("failed to get leaderboard", zap.Error(err))
+		return nil, err
+	}
 
-	g.Go(func() error {
-		var err error
-		fltUserHighSalary, err = u.repo.GetUserHighSalary()
+	entries := make([]*models.LeaderboardEntry, 0, len(r.GetEntries()))
+	userIDs := make([]string, 0, len(r.GetEntries()))
+	for _, entry := range r.GetEntries() {
+		entries = append(entries, &models.LeaderboardEntry{
+			Rank:   int(entry.GetRank()),
+			UserID: entry.GetUserId(),
+			Points: int(entry.GetPoints()),
+		})
+		userIDs = append(userIDs, entry.GetUserId())
+	}
+
+	users, err := u.repo.GetUsersByIDsDB(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+	for _, entry := range entries {
+		entry.Name = names[entry.UserID]
+	}
+
+	logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
+	return entries, nil
+}
+
+// getUserListPoints fetches points for userIDs through the point service circuit breaker.
+func (u *UserService) getUserListPoints(ctx context.Context, userIDs []string) (map[string]int32, error) {
+	logger := logging.FromContext(ctx, u.logger)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var userPoints map[string]int32
+	err := u.pointServiceBreaker.Execute(func() error {
+		conn, err := u.pointServiceConnectionPool.GetContext(ctx)
 		if err != nil {
-			return err
+			return callError(ctx, fmt.Errorf("failed to get connection from pool: %w", err))
 		}
-		return nil
-	})
+		defer u.pointServiceConnectionPool.Put(conn)
 
-	g.Go(func() error {
-		var err error
-		fltUserAvgSalary, err = u.repo.GetUserAvgSalary()
+		r, err := pb.NewPointServerClient(conn).GetUserListPoints(ctx, &pb.UserListRequest{UserIds: userIDs})
 		if err != nil {
-			return err
+			return callError(ctx, err)
 		}
+		userPoints = r.GetUserPoints()
 		return nil
 	})
-
-	if err := g.Wait(); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	userStatistics := &models.UserStatistics{
-		UserList:       userList,
-		Count:          count,
-		UserHighAge:    intUserHighAge,
-		UserLowAge:     intUserLowAge,
-		UserAvgAge:     fltUserAvgAge,
-		UserLowSalary:  fltUserLowSalary,
-		UserHighSalary: fltUserHighSalary,
-		UserAvgSalary:  fltUserAvgSalary,
+	for k, v := range userPoints {
+		logger.Debug("user points", zap.String("user_id", k), zap.Any("points", v))
 	}
+	return userPoints, nil
+}
 
-	u.logger.Debug("method end", zap.String("method_name", methodName), zap.Duration("since", time.Since(start)))
-	return userStatistics, nil
+// callError returns context.Canceled for a call that failed because its caller went away, which
+// gRPC reports as a status error, so the breaker does not count it against point_service.
+func callError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return ctx.Err()
+	}
+	return err
 }
 
+// updateUserListWithPoints sets every user's points; users without a points row have 0.
 func updateUserListWithPoints(userList []*models.User, userPoints map[string]int32) []*models.User {
 	for _, user := range userList {
-		if points, ok := userPoints[user.ID]; ok {
-			user.Point = int(points)
-		}
+		points := int(userPoints[user.ID])
+		user.Point = &points
 	}
 	return userList
 }
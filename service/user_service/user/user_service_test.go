@@ -1,11 +1,15 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/syedomair/backend-microservices/lib/breaker"
 	"github.com/syedomair/backend-microservices/lib/mockgrpc"
+	"github.com/syedomair/backend-microservices/lib/request"
 	"github.com/syedomair/backend-microservices/models"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -14,26 +18,14 @@ import (
 func TestGetAllUserStatistics_Success(t *testing.T) {
 	// Setup mock repository
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
-			return []*models.User{{ID: "1", Name: "John", Point: 10}}, "100", nil
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+			return []*models.User{{ID: "1", Name: "John", Point: intPtr(10)}}, "100", nil
 		},
-		GetUserHighAgeFunc: func() (int, error) {
-			return 40, nil
-		},
-		GetUserLowAgeFunc: func() (int, error) {
-			return 20, nil
-		},
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 30.5, nil
-		},
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 50000.0, nil
-		},
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 150000.0, nil
-		},
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 100000.0, nil
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return &models.UserAggregates{
+				Age:    models.FieldStatistics{Min: 20, Max: 40, Avg: 30.5},
+				Salary: models.FieldStatistics{Min: 50000, Max: 150000, Avg: 100000},
+			}, nil
 		},
 	}
 
@@ -51,48 +43,34 @@ func TestGetAllUserStatistics_Success(t *testing.T) {
 
 	// Initialize service with mock repository
 	logger, _ := zap.NewProduction()
-	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool)
+	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool, nil)
 
 	// Call the method under test
-	result, err := userService.GetAllUserStatistics(10, 0, "id", "asc")
+	result, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
 
 	// Assertions
 	assert.NoError(t, err)
 	assert.Equal(t, &models.UserStatistics{
-		UserList:       []*models.User{{ID: "1", Name: "John", Point: 10}},
-		Count:          "100",
-		UserHighAge:    40,
-		UserLowAge:     20,
-		UserAvgAge:     30.5,
-		UserLowSalary:  50000.0,
-		UserHighSalary: 150000.0,
-		UserAvgSalary:  100000.0,
+		UserList: []*models.User{{ID: "1", Name: "John", Point: intPtr(10)}},
+		Count:    "100",
+		Aggregates: models.UserAggregates{
+			Age:    models.FieldStatistics{Min: 20, Max: 40, Avg: 30.5},
+			Salary: models.FieldStatistics{Min: 50000, Max: 150000, Avg: 100000},
+		},
 	}, result)
 }
 
 func TestGetAllUserStatistics_ErrorInGetAllUserDB(t *testing.T) {
 
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return nil, "", errors.New("database error")
 		},
-		GetUserHighAgeFunc: func() (int, error) {
-			return 40, nil
-		},
-		GetUserLowAgeFunc: func() (int, error) {
-			return 20, nil
-		},
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 30.5, nil
-		},
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 50000.0, nil
-		},
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 150000.0, nil
-		},
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 100000.0, nil
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return &models.UserAggregates{
+				Age:    models.FieldStatistics{Min: 20, Max: 40, Avg: 30.5},
+				Salary: models.FieldStatistics{Min: 50000, Max: 150000, Avg: 100000},
+			}, nil
 		},
 	}
 
@@ -109,10 +87,10 @@ func TestGetAllUserStatistics_ErrorInGetAllUserDB(t *testing.T) {
 	}
 	// Initialize service with mock repository
 	logger, _ := zap.NewProduction()
-	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool)
+	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool, nil)
 
 	// Call the method under test
-	result, err := userService.GetAllUserStatistics(10, 0, "id", "asc")
+	result, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
 
 	// Assertions
 	assert.Error(t, err)
@@ -120,29 +98,14 @@ func TestGetAllUserStatistics_ErrorInGetAllUserDB(t *testing.T) {
 	assert.Nil(t, result)
 }
 
-func TestGetAllUserStatistics_ErrorInGetUserHighAge(t *testing.T) {
+func TestGetAllUserStatistics_ErrorInGetUserAggregatesDB(t *testing.T) {
 
 	mockRepo := &MockRepository{
-		GetAllUserDBFunc: func(limit, offset int, orderBy, sort string) ([]*models.User, string, error) {
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
 			return []*models.User{{ID: "1", Name: "John"}}, "100", nil
 		},
-		GetUserHighAgeFunc: func() (int, error) {
-			return 0, errors.New("database error")
-		},
-		GetUserLowAgeFunc: func() (int, error) {
-			return 20, nil
-		},
-		GetUserAvgAgeFunc: func() (float64, error) {
-			return 30.5, nil
-		},
-		GetUserLowSalaryFunc: func() (float64, error) {
-			return 50000.0, nil
-		},
-		GetUserHighSalaryFunc: func() (float64, error) {
-			return 150000.0, nil
-		},
-		GetUserAvgSalaryFunc: func() (float64, error) {
-			return 100000.0, nil
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return nil, errors.New("database error")
 		},
 	}
 
@@ -159,13 +122,185 @@ func TestGetAllUserStatistics_ErrorInGetUserHighAge(t *testing.T) {
 	}
 	// Initialize service with mock repository
 	logger, _ := zap.NewProduction()
-	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool)
+	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool, nil)
 
 	// Call the method under test
-	result, err := userService.GetAllUserStatistics(10, 0, "id", "asc")
+	result, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
 
 	// Assertions
 	assert.Error(t, err)
 	assert.Equal(t, "database error", err.Error())
 	assert.Nil(t, result)
 }
+
+func TestGetLeaderboard_AttachesNames(t *testing.T) {
+	mockRepo := &MockRepository{
+		GetUsersByIDsDBFunc: func(ctx context.Context, userIDs []string) ([]*models.User, error) {
+			assert.Equal(t, []string{"1", "2", "3"}, userIDs)
+			return []*models.User{{ID: "1", Name: "Alice"}, {ID: "3", Name: "Carol"}}, nil
+		},
+	}
+
+	pointServiceClient, conn, _ := mockgrpc.SetupGRPCServer(t)
+	defer conn.Close()
+
+	mockConnectionPool := &mockgrpc.MockConnectionPool{
+		GetFunc: func() (*grpc.ClientConn, error) {
+			return conn, nil
+		},
+		PutFunc: func(conn *grpc.ClientConn) {},
+	}
+
+	logger, _ := zap.NewProduction()
+	userService := NewUserService(mockRepo, logger, pointServiceClient, mockConnectionPool, nil)
+
+	result, err := userService.GetLeaderboard(context.Background(), 10, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.LeaderboardEntry{
+		{Rank: 1, UserID: "1", Name: "Alice", Points: 20},
+		{Rank: 2, UserID: "2", Name: "", Points: 10},
+		{Rank: 2, UserID: "3", Name: "Carol", Points: 10},
+	}, result)
+}
+
+func TestGetLeaderboard_PoolError(t *testing.T) {
+	mockConnectionPool := &mockgrpc.MockConnectionPool{
+		GetFunc: func() (*grpc.ClientConn, error) {
+			return nil, errors.New("pool exhausted")
+		},
+	}
+
+	logger, _ := zap.NewProduction()
+	userService := NewUserService(&MockRepository{}, logger, nil, mockConnectionPool, nil)
+
+	result, err := userService.GetLeaderboard(context.Background(), 10, "")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGetAllUserStatistics_CancelsSiblingQueries(t *testing.T) {
+	// The aggregate query runs until it is canceled, so the call only returns once the failure canceled it.
+	mockRepo := &MockRepository{
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+			return nil, "", errors.New("database error")
+		},
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil, errors.New("query was not canceled")
+			}
+		},
+	}
+
+	userService := NewUserService(mockRepo, zap.NewNop(), nil, &mockgrpc.MockConnectionPool{}, nil)
+
+	start := time.Now()
+	result, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
+
+	assert.EqualError(t, err, "database error")
+	assert.Nil(t, result)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestGetAllUserStatistics_CallerCanceled(t *testing.T) {
+	mockRepo := statisticsRepo()
+	mockRepo.GetAllUserDBFunc = func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+		return nil, "", ctx.Err()
+	}
+	pointBreaker := breaker.New("point_service_canceled_test", breaker.Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	mockConnectionPool := &mockgrpc.MockConnectionPool{
+		GetContextFunc: func(ctx context.Context) (*grpc.ClientConn, error) {
+			return nil, ctx.Err()
+		},
+	}
+	userService := NewUserService(mockRepo, zap.NewNop(), nil, mockConnectionPool, pointBreaker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := userService.GetAllUserStatistics(ctx, 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The leaderboard call the client abandoned does not count against point_service.
+	_, err = userService.GetLeaderboard(ctx, 10, "")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, breaker.StateClosed, pointBreaker.State())
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func statisticsRepo() *MockRepository {
+	return &MockRepository{
+		GetAllUserDBFunc: func(ctx context.Context, limit, offset int, orderBy string, keyset *request.Keyset, filter *request.Filter) ([]*models.User, string, error) {
+			return []*models.User{{ID: "1", Name: "John"}}, "1", nil
+		},
+		GetUserAggregatesDBFunc: func(ctx context.Context, filter *request.Filter) (*models.UserAggregates, error) {
+			return &models.UserAggregates{
+				Age:    models.FieldStatistics{Count: 1, Min: 20, Max: 40, Avg: 30.5, Median: 30},
+				Salary: models.FieldStatistics{Min: 50000, Max: 150000, Avg: 100000},
+			}, nil
+		},
+	}
+}
+
+func TestGetAllUserStatistics_PointServiceUnavailable(t *testing.T) {
+	mockConnectionPool := &mockgrpc.MockConnectionPool{
+		GetFunc: func() (*grpc.ClientConn, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+
+	logger, _ := zap.NewProduction()
+	userService := NewUserService(statisticsRepo(), logger, nil, mockConnectionPool, nil)
+
+	result, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.DegradedPoints}, result.Degraded)
+	assert.Len(t, result.UserList, 1)
+	assert.Nil(t, result.UserList[0].Point)
+	assert.Equal(t, 40.0, result.Aggregates.Age.Max)
+}
+
+func TestGetAllUserStatistics_BreakerOpen(t *testing.T) {
+	calls := 0
+	mockConnectionPool := &mockgrpc.MockConnectionPool{
+		GetFunc: func() (*grpc.ClientConn, error) {
+			calls++
+			return nil, errors.New("connection refused")
+		},
+	}
+
+	logger, _ := zap.NewProduction()
+	pointBreaker := breaker.New("test_point_service", breaker.Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	userService := NewUserService(statisticsRepo(), logger, nil, mockConnectionPool, pointBreaker)
+
+	_, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
+	assert.NoError(t, err)
+	assert.Equal(t, breaker.StateOpen, pointBreaker.State())
+
+	// The open breaker skips point_service entirely and still degrades gracefully.
+	result, err := userService.GetAllUserStatistics(context.Background(), 10, 0, "id ASC", nil, nil, StatsScopeGlobal)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{models.DegradedPoints}, result.Degraded)
+	assert.Nil(t, result.UserList[0].Point)
+}
+
+func TestGetLeaderboard_BreakerOpen(t *testing.T) {
+	pointBreaker := breaker.New("test_point_service", breaker.Settings{FailureThreshold: 1, OpenTimeout: time.Minute})
+	_ = pointBreaker.Execute(func() error { return errors.New("down") })
+
+	logger, _ := zap.NewProduction()
+	userService := NewUserService(&MockRepository{}, logger, nil, &mockgrpc.MockConnectionPool{}, pointBreaker)
+
+	result, err := userService.GetLeaderboard(context.Background(), 10, "")
+
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Nil(t, result)
+}